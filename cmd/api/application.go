@@ -2,28 +2,115 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/manas-solves/realworld-backend/internal/auth"
 	"github.com/manas-solves/realworld-backend/internal/data"
 )
 
 type appConfig struct {
-	port     int
-	env      string
-	db       dbConfig
-	jwtMaker jwtMakerConfig
+	port                   int
+	env                    string
+	baseURL                string
+	readOnly               bool
+	tls                    tlsConfig
+	db                     dbConfig
+	jwtMaker               jwtMakerConfig
+	emailValidation        string
+	blockedDomainsFile     string
+	blockedTagsFile        string
+	defaultImage           string
+	httpsOnlyURLs          bool
+	maxFollows             int
+	maxArticles            int
+	maxResponseTags        int
+	maxPageOffset          int
+	maxSlugLength          int
+	maxComments            int
+	maxFollowsPageSize     int
+	maxBatchSlugs          int
+	tagPolicy              string
+	forbidSelfFavorite     bool
+	computedFavoritesCount bool
+	feedEmptyHint          bool
+	userArticlesCount      bool
+	authCookie             bool
+	snakeCaseJSON          bool
+	genericListEnvelope    bool
+	sortValidationErrors   bool
+	contentSecurityPolicy  string
+	allowedHTMLTags        string
+	debugNewTags           bool
+	excerptLength          int
+	strictSelfUnfollow     bool
+	defaultTimezone        string
+	commentLimit           commentLimitConfig
+	articleCooldown        time.Duration
+	passwordHash           passwordHashConfig
+	defaultSort            defaultSortConfig
+	userCache              userCacheConfig
+}
+
+type tlsConfig struct {
+	certFile string
+	keyFile  string
+}
+
+type userCacheConfig struct {
+	enabled bool
+}
+
+// passwordHashConfig selects the algorithm, one of data.PasswordHashAlgorithms, and the
+// bcrypt cost and argon2id parameters used for new password hashes.
+type passwordHashConfig struct {
+	algorithm         string
+	bcryptCost        int
+	argon2Memory      uint // KiB
+	argon2Iterations  uint
+	argon2Parallelism uint
+}
+
+// hashing returns the password hashing settings for the data package.
+func (c passwordHashConfig) hashing() data.PasswordHashing {
+	return data.PasswordHashing{
+		Algorithm:  c.algorithm,
+		BcryptCost: c.bcryptCost,
+		Argon2: data.Argon2Params{
+			Memory:      uint32(c.argon2Memory),
+			Iterations:  uint32(c.argon2Iterations),
+			Parallelism: uint8(c.argon2Parallelism),
+		},
+	}
+}
+
+type commentLimitConfig struct {
+	max    int
+	window time.Duration
+}
+
+// defaultSortConfig holds the orderings, one of data.ArticleSorts, used when a list
+// request doesn't pass a sort parameter.
+type defaultSortConfig struct {
+	list string
+	feed string
 }
 
 type dbConfig struct {
-	dsn          string
-	maxIdleTime  time.Duration
-	maxOpenConns int
-	timeout      time.Duration
+	dsn               string
+	maxIdleTime       time.Duration
+	maxOpenConns      int
+	minConns          int           // Connections kept open even when idle, to absorb bursts
+	healthCheckPeriod time.Duration // How often idle connections are checked and reaped
+	timeout           time.Duration
+	retryAttempts     int
+	retryBackoff      time.Duration
+	countQueries      bool // Count the queries run on the pool; only enabled by tests
 }
 
 type jwtMakerConfig struct {
@@ -32,14 +119,63 @@ type jwtMakerConfig struct {
 	accessDuration time.Duration
 }
 
+// LogValue implements slog.LogValuer so the resolved config can be logged at startup.
+// The database DSN and JWT secret are deliberately left out.
 func (c appConfig) LogValue() slog.Value {
 	return slog.GroupValue(
 		slog.Int("port", c.port),
 		slog.String("env", c.env),
+		slog.Bool("read-only", c.readOnly),
+		slog.String("base-url", c.baseURL),
+		slog.Bool("tls", c.tls.certFile != ""),
 
 		slog.Int("db-max-open-conns", c.db.maxOpenConns),
+		slog.Int("db-min-conns", c.db.minConns),
+		slog.Duration("db-health-check-period", c.db.healthCheckPeriod),
 		slog.Duration("db-max-idle-time", c.db.maxIdleTime),
 		slog.Duration("db-timeout", c.db.timeout),
+		slog.Int("db-retry-attempts", c.db.retryAttempts),
+		slog.Duration("db-retry-backoff", c.db.retryBackoff),
+
+		slog.String("email-validation", c.emailValidation),
+		slog.String("blocked-email-domains-file", c.blockedDomainsFile),
+		slog.String("blocked-tags-file", c.blockedTagsFile),
+		slog.String("default-avatar-url", c.defaultImage),
+		slog.Bool("https-only-urls", c.httpsOnlyURLs),
+		slog.String("password-hash", c.passwordHash.algorithm),
+		slog.Int("bcrypt-cost", c.passwordHash.bcryptCost),
+		slog.Uint64("argon2-memory", uint64(c.passwordHash.argon2Memory)),
+		slog.Uint64("argon2-iterations", uint64(c.passwordHash.argon2Iterations)),
+		slog.Uint64("argon2-parallelism", uint64(c.passwordHash.argon2Parallelism)),
+		slog.Int("max-follows", c.maxFollows),
+		slog.Int("max-articles-per-user", c.maxArticles),
+		slog.Int("max-response-tags", c.maxResponseTags),
+		slog.Int("max-page-offset", c.maxPageOffset),
+		slog.Int("max-slug-length", c.maxSlugLength),
+		slog.Int("max-comments", c.maxComments),
+		slog.Int("max-follows-page-size", c.maxFollowsPageSize),
+		slog.Int("max-batch-slugs", c.maxBatchSlugs),
+		slog.String("duplicate-tags", c.tagPolicy),
+		slog.Bool("forbid-self-favorite", c.forbidSelfFavorite),
+		slog.Bool("computed-favorites-count", c.computedFavoritesCount),
+		slog.Bool("user-cache-enabled", c.userCache.enabled),
+		slog.Int("comment-limit-max", c.commentLimit.max),
+		slog.Duration("comment-limit-window", c.commentLimit.window),
+		slog.Duration("article-cooldown", c.articleCooldown),
+		slog.String("list-default-sort", c.defaultSort.list),
+		slog.String("feed-default-sort", c.defaultSort.feed),
+		slog.Bool("feed-empty-hint", c.feedEmptyHint),
+		slog.Bool("user-articles-count", c.userArticlesCount),
+		slog.Bool("auth-cookie", c.authCookie),
+		slog.Bool("snake-case-json", c.snakeCaseJSON),
+		slog.Bool("generic-list-envelope", c.genericListEnvelope),
+		slog.Bool("sort-validation-errors", c.sortValidationErrors),
+		slog.String("content-security-policy", c.contentSecurityPolicy),
+		slog.String("allowed-html-tags", c.allowedHTMLTags),
+		slog.Bool("debug-new-tags", c.debugNewTags),
+		slog.Int("description-excerpt-length", c.excerptLength),
+		slog.Bool("strict-self-unfollow", c.strictSelfUnfollow),
+		slog.String("default-timezone", c.defaultTimezone),
 
 		slog.String("version", version),
 	)
@@ -51,7 +187,22 @@ type application struct {
 	modelStore data.ModelStore
 	jwtMaker   jwtMaker
 	wg         sync.WaitGroup
-	userCache  *data.UserCache
+	// userCache is nil when the user cache is disabled.
+	userCache *data.UserCache
+	// commentLimiter is nil when comment rate limiting is disabled.
+	commentLimiter *commentLimiter
+	// articleCooldown is nil when the article creation cooldown is disabled.
+	articleCooldown *articleCooldown
+	// blockedDomains is nil when no email domain blocklist is configured.
+	blockedDomains emailDomainBlocklist
+	// blockedTags is nil when no tag blocklist is configured.
+	blockedTags tagBlocklist
+	// defaultTimezone is nil when no default time zone is configured.
+	defaultTimezone *time.Location
+	// routeIndex is a flattened copy of the routes, used to list allowed methods.
+	routeIndex *chi.Mux
+	// queryCounter is nil unless query counting is enabled.
+	queryCounter *data.QueryCounter
 }
 
 type jwtMaker interface {
@@ -66,27 +217,102 @@ func newApplication(config appConfig, logger *slog.Logger) *application {
 		os.Exit(1)
 	}
 
-	// Cache users for 15 minutes, cleanup expired items every 10 minutes
-	userCache := data.NewUserCache(15*time.Minute, 10*time.Minute)
+	// Cache users for 15 minutes, cleanup expired items every 10 minutes.
+	// A nil cache makes the user store go straight to the database.
+	var userCache *data.UserCache
+	if config.userCache.enabled {
+		userCache = data.NewUserCache(15*time.Minute, 10*time.Minute)
+	}
+
+	var queryCounter *data.QueryCounter
+	if config.db.countQueries {
+		queryCounter = &data.QueryCounter{}
+	}
+
+	app := &application{
+		config:       config,
+		logger:       logger,
+		modelStore:   newModelStore(config, userCache, queryCounter),
+		jwtMaker:     jwtMaker,
+		userCache:    userCache,
+		queryCounter: queryCounter,
+	}
+
+	if config.commentLimit.max > 0 {
+		app.commentLimiter = newCommentLimiter(config.commentLimit.max, config.commentLimit.window)
+	}
+
+	if config.articleCooldown > 0 {
+		app.articleCooldown = newArticleCooldown(config.articleCooldown)
+	}
+
+	if config.blockedDomainsFile != "" {
+		domains, err := loadBlocklist(config.blockedDomainsFile)
+		if err != nil {
+			slog.Error("failed to load blocked email domains", "file", config.blockedDomainsFile, "error", err)
+			os.Exit(1)
+		}
+		app.blockedDomains = emailDomainBlocklist(domains)
+	}
+
+	if config.blockedTagsFile != "" {
+		tags, err := loadBlocklist(config.blockedTagsFile)
+		if err != nil {
+			slog.Error("failed to load blocked tags", "file", config.blockedTagsFile, "error", err)
+			os.Exit(1)
+		}
+		app.blockedTags = tagBlocklist(tags)
+	}
+
+	if config.defaultTimezone != "" {
+		app.defaultTimezone, err = loadTimezone(config.defaultTimezone)
+		if err != nil {
+			slog.Error("failed to load default time zone", "timezone", config.defaultTimezone, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	return app
+}
+
+// newPoolConfig builds the pgx pool config for the database config, tracing queries with
+// queryCounter when it is not nil.
+func newPoolConfig(config dbConfig, queryCounter *data.QueryCounter) (*pgxpool.Config, error) {
+	pgxConf, err := pgxpool.ParseConfig(config.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if config.minConns > config.maxOpenConns {
+		return nil, fmt.Errorf("db-min-conns (%d) must not be more than db-max-open-conns (%d)", config.minConns, config.maxOpenConns)
+	}
 
-	return &application{
-		config:     config,
-		logger:     logger,
-		modelStore: newModelStore(config, userCache),
-		jwtMaker:   jwtMaker,
-		userCache:  userCache,
+	pgxConf.MaxConnIdleTime = config.maxIdleTime
+	pgxConf.MaxConns = int32(config.maxOpenConns)
+	pgxConf.MinConns = int32(config.minConns)
+	if config.healthCheckPeriod > 0 {
+		pgxConf.HealthCheckPeriod = config.healthCheckPeriod
 	}
+	if queryCounter != nil {
+		pgxConf.ConnConfig.Tracer = queryCounter
+	}
+
+	return pgxConf, nil
 }
 
-func newModelStore(config appConfig, userCache *data.UserCache) data.ModelStore {
-	pgxConf, err := pgxpool.ParseConfig(config.db.dsn)
+func newModelStore(config appConfig, userCache *data.UserCache, queryCounter *data.QueryCounter) data.ModelStore {
+	pgxConf, err := newPoolConfig(config.db, queryCounter)
 	if err != nil {
 		slog.Error(err.Error())
-		slog.Error("cannot parse database dsn", "dsn", config.db.dsn)
+		slog.Error("cannot build database pool config", "dsn", config.db.dsn)
 		os.Exit(1)
 	}
-	pgxConf.MaxConnIdleTime = config.db.maxIdleTime
-	pgxConf.MaxConns = int32(config.db.maxOpenConns)
+	slog.Info("database pool configured",
+		"max-conns", pgxConf.MaxConns,
+		"min-conns", pgxConf.MinConns,
+		"max-conn-idle-time", pgxConf.MaxConnIdleTime,
+		"max-conn-lifetime", pgxConf.MaxConnLifetime,
+		"health-check-period", pgxConf.HealthCheckPeriod,
+	)
 
 	db, err := pgxpool.NewWithConfig(context.Background(), pgxConf)
 	if err != nil {
@@ -105,5 +331,18 @@ func newModelStore(config appConfig, userCache *data.UserCache) data.ModelStore
 		os.Exit(1)
 	}
 
-	return data.NewModelStore(db, config.db.timeout, userCache)
+	opts := data.Options{
+		MaxFollows:             config.maxFollows,
+		MaxArticles:            config.maxArticles,
+		ForbidSelfFavorite:     config.forbidSelfFavorite,
+		MaxSlugLength:          config.maxSlugLength,
+		ComputedFavoritesCount: config.computedFavoritesCount,
+		TagPolicy:              config.tagPolicy,
+		ReadRetry: data.RetryPolicy{
+			Attempts: config.db.retryAttempts,
+			Backoff:  config.db.retryBackoff,
+		},
+	}
+
+	return data.NewModelStore(db, config.db.timeout, userCache, opts)
 }
@@ -2,13 +2,25 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/manas-solves/realworld-backend/internal/data"
+	"github.com/manas-solves/realworld-backend/internal/markdown"
 	"github.com/manas-solves/realworld-backend/internal/validator"
 	"github.com/go-chi/chi/v5"
 )
 
+// limitTags truncates the article's (sorted) tag list to the configured maximum for
+// display, if there is one. The stored tags are left untouched.
+func (app *application) limitTags(article *data.Article) {
+	if app.config.maxResponseTags > 0 && len(article.TagList) > app.config.maxResponseTags {
+		article.TagList = article.TagList[:app.config.maxResponseTags]
+	}
+}
+
 func (app *application) listArticlesHandler(w http.ResponseWriter, r *http.Request) {
 	// Read pagination parameters using reusable helper
 	// Default limit is 20, max limit is 100
@@ -19,36 +31,67 @@ func (app *application) listArticlesHandler(w http.ResponseWriter, r *http.Reque
 
 	// Read filters
 	filters := data.ArticleFilters{
-		Tag:       qs.Get("tag"),
+		Tags:      app.readValues(qs, "tag"),
 		Author:    qs.Get("author"),
 		Favorited: qs.Get("favorited"),
+		Search:    strings.TrimSpace(qs.Get("search")),
+		Sort:      app.readString(qs, "sort", app.config.defaultSort.list),
+		Fields:    app.readCSV(qs, "fields"),
 		Limit:     pagination.Limit,
 		Offset:    pagination.Offset,
 	}
 
+	// Get current user (may be anonymous)
+	currentUser := app.contextGetUser(r)
+
+	// Resolve the "me" alias to the authenticated user's username
+	if filters.Author == meAlias {
+		if currentUser.IsAnonymous() {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+		filters.Author = currentUser.Username
+	}
+
+	timezone, ok := app.readTimezone(r)
+
 	// Validate filters
 	v := validator.New()
+	pagination.Validate(v, app.config.maxPageOffset)
 	filters.Validate(v)
+	v.Check(ok, timezoneErrorMessage)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	// Get current user (may be anonymous)
-	currentUser := app.contextGetUser(r)
-
 	// List articles with filters
-	articles, totalCount, err := app.modelStore.Articles.List(filters, currentUser)
+	articles, totalCount, err := app.modelStore.Articles.List(r.Context(), filters, currentUser)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
+
+	// Marshal only the requested fields when a sparse fieldset was asked for
+	var articlesData any = articles
+	if len(filters.Fields) > 0 {
+		projected := make([]map[string]any, len(articles))
+		for i := range articles {
+			projected[i] = articles[i].Project(filters.Fields)
+		}
+		articlesData = projected
+	}
 
 	// Write response
-	err = app.writeJSON(w, http.StatusOK, envelope{
-		"articles":      articles,
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", envelope{
+		"articles":      articlesData,
 		"articlesCount": totalCount,
-	}, nil)
+		"pagination":    pagination.Metadata(totalCount),
+	}), nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -65,23 +108,243 @@ func (app *application) feedArticlesHandler(w http.ResponseWriter, r *http.Reque
 
 	// Create filters for feed - only get articles from followed users
 	filters := data.ArticleFilters{
-		Feed:   true,
-		Limit:  pagination.Limit,
-		Offset: pagination.Offset,
+		Feed:      true,
+		Algorithm: r.URL.Query().Get("algorithm"),
+		Sort:      app.readString(r.URL.Query(), "sort", app.config.defaultSort.feed),
+		Limit:     pagination.Limit,
+		Offset:    pagination.Offset,
+	}
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	pagination.Validate(v, app.config.maxPageOffset)
+	filters.Validate(v)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
 	// Get articles using List method with Feed filter
-	articles, totalCount, err := app.modelStore.Articles.List(filters, currentUser)
+	articles, totalCount, err := app.modelStore.Articles.List(r.Context(), filters, currentUser)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
 
 	// Write response
-	err = app.writeJSON(w, http.StatusOK, envelope{
+	env := envelope{
 		"articles":      articles,
 		"articlesCount": totalCount,
-	}, nil)
+		"pagination":    pagination.Metadata(totalCount),
+	}
+
+	// Tell the client why the feed is empty, so it can suggest authors to follow
+	if app.config.feedEmptyHint && totalCount == 0 && pagination.Offset == 0 {
+		following, err := app.modelStore.Users.CountFollowing(r.Context(), currentUser.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		reason := feedEmptyNoArticlesFromFollows
+		if following == 0 {
+			reason = feedEmptyNotFollowingAnyone
+		}
+		env["meta"] = envelope{"isEmpty": true, "reason": reason}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", env), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// Reasons reported in the feed's meta field when the feed is empty.
+const (
+	feedEmptyNotFollowingAnyone    = "not_following_anyone"
+	feedEmptyNoArticlesFromFollows = "no_articles_from_follows"
+)
+
+// commentedArticlesHandler lists the articles a user has commented on, most recently
+// commented first.
+func (app *application) commentedArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	pagination.Validate(v, app.config.maxPageOffset)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	author, err := app.modelStore.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	articles, totalCount, err := app.modelStore.Articles.CommentedBy(r.Context(), author.ID, pagination.Limit, pagination.Offset, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", envelope{
+		"articles":      articles,
+		"articlesCount": totalCount,
+		"pagination":    pagination.Metadata(totalCount),
+	}), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// batchArticlesHandler returns the articles with the requested slugs, in the requested
+// order. Slugs that don't match an article are left out of the response.
+func (app *application) batchArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Slugs []string `json:"slugs"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	v.Check(len(input.Slugs) > 0, "slugs must be provided")
+	v.Check(len(input.Slugs) <= app.config.maxBatchSlugs,
+		fmt.Sprintf("slugs must not contain more than %d entries", app.config.maxBatchSlugs))
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	articles, err := app.modelStore.Articles.GetBySlugs(r.Context(), input.Slugs, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", envelope{
+		"articles":      articles,
+		"articlesCount": len(articles),
+	}), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// maxTrendingDays is the largest time window, in days, accepted by the trending endpoint.
+const maxTrendingDays = 365
+
+func (app *application) trendingArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	// Default limit is 10, max limit is 100; the leaderboard has no offset
+	pagination := app.readPagination(r, 10, 100)
+
+	// Optional time window in days; 0 ranks by all-time favorites
+	qs := r.URL.Query()
+	days := app.readInt(qs.Get("days"), 0)
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	v.Check(days >= 0 && days <= maxTrendingDays, fmt.Sprintf("Days must be between 0 and %d", maxTrendingDays))
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	articles, err := app.modelStore.Articles.Trending(r.Context(), days, pagination.Limit, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", envelope{
+		"articles":      articles,
+		"articlesCount": len(articles),
+	}), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// relatedArticlesHandler returns the articles sharing the most tags with the given article.
+// Other articles by the same author can be left out with excludeAuthor=true.
+func (app *application) relatedArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	// Default limit is 5, max limit is 20; related articles have no offset
+	pagination := app.readPagination(r, 5, 20)
+
+	timezone, ok := app.readTimezone(r)
+
+	excludeAuthor := false
+	v := validator.New()
+	if value := r.URL.Query().Get("excludeAuthor"); value != "" {
+		var err error
+		excludeAuthor, err = strconv.ParseBool(value)
+		v.Check(err == nil, "excludeAuthor must be true or false")
+	}
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	slug := chi.URLParam(r, "slug")
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.articleNotFoundResponse(w, r, slug)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	articles, err := app.modelStore.Articles.Related(r.Context(), articleID, pagination.Limit, excludeAuthor, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	for i := range articles {
+		app.limitTags(&articles[i])
+		articles[i].Localize(timezone)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("articles", envelope{
+		"articles":      articles,
+		"articlesCount": len(articles),
+	}), nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -94,6 +357,8 @@ func (app *application) createArticleHandler(w http.ResponseWriter, r *http.Requ
 			Title       string   `json:"title"`
 			Description string   `json:"description"`
 			Body        string   `json:"body"`
+			BodyType    string   `json:"bodyType"`
+			CoverImage  string   `json:"coverImage"`
 			TagList     []string `json:"tagList"`
 		} `json:"article"`
 	}
@@ -108,70 +373,277 @@ func (app *application) createArticleHandler(w http.ResponseWriter, r *http.Requ
 		Title:       input.Article.Title,
 		Description: input.Article.Description,
 		Body:        input.Article.Body,
+		BodyType:    input.Article.BodyType,
+		CoverImage:  input.Article.CoverImage,
 		TagList:     input.Article.TagList,
 		AuthorID:    app.contextGetUser(r).ID,
 	}
+	if article.BodyType == "" {
+		article.BodyType = data.BodyTypeMarkdown
+	}
+	article.DefaultDescription(app.config.excerptLength)
+
+	// Normalize tags before validation so that "Golang" and "golang" count as duplicates
+	article.NormalizeTags()
+	if app.config.tagPolicy == data.TagPolicyDedupe {
+		article.DedupeTags()
+	}
+
+	timezone, ok := app.readTimezone(r)
 
 	v := validator.New()
 
-	if data.ValidateArticle(v, article); !v.Valid() {
+	data.ValidateArticle(v, article)
+	for _, tag := range article.TagList {
+		v.Check(!app.blockedTags.Blocked(tag), fmt.Sprintf("tag %q is not allowed", tag))
+	}
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
+	// Enforce the per-user creation cooldown if one is configured. This is checked last so
+	// that rejected requests don't start a cooldown.
+	if app.articleCooldown != nil {
+		if ok, retryAfter := app.articleCooldown.Allow(article.AuthorID); !ok {
+			app.rateLimitExceededResponse(w, r, retryAfter)
+			return
+		}
+	}
+
 	// Insert article and get complete article with author in a single query
 	// Tags are inserted synchronously as part of the article insertion
-	createdArticle, err := app.modelStore.Articles.InsertAndReturn(article, app.contextGetUser(r))
+	// The per-user article quota, if configured, is enforced by the store
+	createdArticle, err := app.modelStore.Articles.InsertAndReturn(r.Context(), article, app.contextGetUser(r))
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		// Nothing was created, so don't make the user wait out the cooldown to retry
+		if app.articleCooldown != nil {
+			app.articleCooldown.Release(article.AuthorID)
+		}
+		switch {
+		case errors.Is(err, data.ErrArticleLimitExceeded):
+			app.failedValidationResponse(w, r, []string{fmt.Sprintf("cannot create more than %d articles", app.config.maxArticles)})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
+	createdArticle.Localize(timezone)
 
 	// Return response with created article
 	headers := make(http.Header)
-	headers.Set("Location", "/articles/"+createdArticle.Slug)
-	err = app.writeJSON(w, http.StatusCreated, envelope{"article": createdArticle}, headers)
+	headers.Set("Location", app.articleURL(createdArticle.Slug))
+	response := envelope{"article": createdArticle}
+	// Report which tags this article introduced, for tag moderation
+	if app.config.debugNewTags {
+		response["newTags"] = createdArticle.NewTags
+	}
+	err = app.writeJSON(w, http.StatusCreated, response, headers)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// getArticleHandler returns an article. With includeComments=true the article's latest
+// comments, up to the configured maximum, are returned alongside it from a single store call.
 func (app *application) getArticleHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
-	article, err := app.modelStore.Articles.GetBySlug(slug, app.contextGetUser(r))
+	includeComments := false
+	if value := r.URL.Query().Get("includeComments"); value != "" {
+		var err error
+		includeComments, err = strconv.ParseBool(value)
+		if err != nil {
+			app.failedValidationResponse(w, r, []string{"includeComments must be true or false"})
+			return
+		}
+	}
+
+	timezone, ok := app.readTimezone(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{timezoneErrorMessage})
+		return
+	}
+
+	var article *data.Article
+	var comments []data.Comment
+	var commentsCount int
+	var err error
+	if includeComments {
+		article, comments, commentsCount, err = app.modelStore.Articles.GetBySlugWithComments(r.Context(), slug, app.contextGetUser(r), app.config.maxComments)
+	} else {
+		article, err = app.modelStore.Articles.GetBySlug(r.Context(), slug, app.contextGetUser(r))
+	}
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
+			app.articleNotFoundResponse(w, r, slug)
 			return
 		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.limitTags(article)
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil)
+	// Optionally render the body to sanitized HTML, leaving the raw body intact
+	renderHTML, ok := readRenderHTML(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{"render must be html"})
+		return
+	}
+	if renderHTML {
+		article.BodyHTML, err = renderBodyHTML(article.BodyType, article.Body)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		for i := range comments {
+			comments[i].BodyHTML, err = renderBodyHTML(comments[i].BodyType, comments[i].Body)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+	}
+
+	// Optionally add the timestamps in the requested time zone, keeping the UTC ones
+	article.Localize(timezone)
+	for i := range comments {
+		comments[i].Localize(timezone)
+	}
+
+	response := envelope{"article": article}
+	if includeComments {
+		// The total lets clients tell whether the comments were cut off at the limit
+		response["comments"] = comments
+		response["commentsCount"] = commentsCount
+	}
+	err = app.writeJSON(w, http.StatusOK, response, versionETag(article))
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// readRenderHTML reports whether the render query parameter asks for bodies rendered to
+// HTML. ok is false when the parameter has any value other than html.
+func readRenderHTML(r *http.Request) (renderHTML, ok bool) {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return false, true
+	case "html":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// renderBodyHTML renders an article or comment body of the given body type to sanitized HTML.
+func renderBodyHTML(bodyType, body string) (string, error) {
+	if bodyType == data.BodyTypePlain {
+		return markdown.PlainToHTML(body), nil
+	}
+	return markdown.ToHTML(body)
+}
+
+// articleNotFoundResponse sends a 410 Gone for slugs of deleted articles, so that clients
+// and crawlers can tell them apart from slugs that never existed, and a 404 otherwise.
+// Every route addressing an article by slug uses it when no article has the slug.
+func (app *application) articleNotFoundResponse(w http.ResponseWriter, r *http.Request, slug string) {
+	deleted, err := app.modelStore.Articles.WasDeleted(r.Context(), slug)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if deleted {
+		app.goneResponse(w, r)
+		return
+	}
+	app.notFoundResponse(w, r)
+}
+
+// versionETag returns a header set holding an ETag for the article's version, which
+// clients can send back in If-Match to make a favorite conditional.
+func versionETag(article *data.Article) http.Header {
+	headers := make(http.Header)
+	headers.Set("ETag", `"`+strconv.Itoa(article.Version)+`"`)
+	return headers
+}
+
+// readIfMatchVersion reads the article version from the If-Match header. It returns 0
+// when the header is absent or "*", meaning no version check. ok is false when the
+// header isn't a version ETag, in which case it can never match.
+func readIfMatchVersion(r *http.Request) (version int, ok bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
 func (app *application) favoriteArticleHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
+	notFound := func(w http.ResponseWriter, r *http.Request) { app.articleNotFoundResponse(w, r, slug) }
+	app.favoriteArticle(w, r, notFound, func(userID int64, version int) (*data.Article, error) {
+		return app.modelStore.Articles.FavoriteBySlug(r.Context(), slug, userID, version)
+	})
+}
+
+// favoriteArticleByIDHandler favorites an article by its ID, for tools that don't have the slug.
+func (app *application) favoriteArticleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := readIDParam(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{idErrorMessage})
+		return
+	}
+
+	app.favoriteArticle(w, r, app.notFoundResponse, func(userID int64, version int) (*data.Article, error) {
+		return app.modelStore.Articles.FavoriteByID(r.Context(), id, userID, version)
+	})
+}
+
+// favoriteArticle favorites an article for the current user with the given store lookup,
+// honouring an If-Match version, and writes the updated article. notFound responds when
+// the article doesn't exist.
+func (app *application) favoriteArticle(w http.ResponseWriter, r *http.Request, notFound http.HandlerFunc, favorite func(userID int64, version int) (*data.Article, error)) {
 	user := app.contextGetUser(r)
 
-	article, err := app.modelStore.Articles.FavoriteBySlug(slug, user.ID)
+	version, ok := readIfMatchVersion(r)
+	if !ok {
+		app.preconditionFailedResponse(w, r)
+		return
+	}
+
+	timezone, ok := app.readTimezone(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{timezoneErrorMessage})
+		return
+	}
+
+	article, err := favorite(user.ID, version)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
-			return
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			notFound(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.preconditionFailedResponse(w, r)
+		case errors.Is(err, data.ErrSelfFavorite):
+			app.failedValidationResponse(w, r, []string{"cannot favorite your own article"})
+		default:
+			app.serverErrorResponse(w, r, err)
 		}
-		app.serverErrorResponse(w, r, err)
 		return
 	}
+	article.Localize(timezone)
 
-	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil); err != nil {
+	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, versionETag(article)); err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -179,19 +651,58 @@ func (app *application) favoriteArticleHandler(w http.ResponseWriter, r *http.Re
 
 func (app *application) unfavoriteArticleHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
+	notFound := func(w http.ResponseWriter, r *http.Request) { app.articleNotFoundResponse(w, r, slug) }
+	app.unfavoriteArticle(w, r, notFound, func(userID int64, version int) (*data.Article, error) {
+		return app.modelStore.Articles.UnfavoriteBySlug(r.Context(), slug, userID, version)
+	})
+}
+
+// unfavoriteArticleByIDHandler unfavorites an article by its ID, for tools that don't have the slug.
+func (app *application) unfavoriteArticleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := readIDParam(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{idErrorMessage})
+		return
+	}
+
+	app.unfavoriteArticle(w, r, app.notFoundResponse, func(userID int64, version int) (*data.Article, error) {
+		return app.modelStore.Articles.UnfavoriteByID(r.Context(), id, userID, version)
+	})
+}
+
+// unfavoriteArticle unfavorites an article for the current user with the given store lookup,
+// honouring an If-Match version, and writes the updated article. notFound responds when
+// the article doesn't exist.
+func (app *application) unfavoriteArticle(w http.ResponseWriter, r *http.Request, notFound http.HandlerFunc, unfavorite func(userID int64, version int) (*data.Article, error)) {
 	user := app.contextGetUser(r)
 
-	article, err := app.modelStore.Articles.UnfavoriteBySlug(slug, user.ID)
+	version, ok := readIfMatchVersion(r)
+	if !ok {
+		app.preconditionFailedResponse(w, r)
+		return
+	}
+
+	timezone, ok := app.readTimezone(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{timezoneErrorMessage})
+		return
+	}
+
+	article, err := unfavorite(user.ID, version)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
-			return
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			notFound(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.preconditionFailedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
 		}
-		app.serverErrorResponse(w, r, err)
 		return
 	}
+	article.Localize(timezone)
 
-	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil); err != nil {
+	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, versionETag(article)); err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -201,11 +712,11 @@ func (app *application) deleteArticleHandler(w http.ResponseWriter, r *http.Requ
 	slug := chi.URLParam(r, "slug")
 	user := app.contextGetUser(r)
 
-	err := app.modelStore.Articles.DeleteBySlug(slug, user.ID)
+	err := app.modelStore.Articles.DeleteBySlug(r.Context(), slug, user.ID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
+			app.deleteArticleNotFoundResponse(w, r, slug)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -215,14 +726,29 @@ func (app *application) deleteArticleHandler(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// deleteArticleNotFoundResponse responds to a delete that matched no article of the current
+// user. An article at the slug belongs to another user and stays a 404, like before, while
+// a missing slug is reported by articleNotFoundResponse, so that deleting twice is a 410.
+func (app *application) deleteArticleNotFoundResponse(w http.ResponseWriter, r *http.Request, slug string) {
+	_, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
+	switch {
+	case err == nil:
+		app.notFoundResponse(w, r)
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.articleNotFoundResponse(w, r, slug)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 	user := app.contextGetUser(r)
 
-	article, err := app.modelStore.Articles.GetBySlug(slug, user)
+	article, err := app.modelStore.Articles.GetBySlug(r.Context(), slug, user)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
+			app.articleNotFoundResponse(w, r, slug)
 			return
 		}
 		app.serverErrorResponse(w, r, err)
@@ -239,6 +765,8 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 			Title       *string `json:"title"`
 			Description *string `json:"description"`
 			Body        *string `json:"body"`
+			BodyType    *string `json:"bodyType"`
+			CoverImage  *string `json:"coverImage"`
 		} `json:"article"`
 	}
 
@@ -250,7 +778,7 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 
 	if input.Article.Title != nil {
 		article.Title = *input.Article.Title
-		article.GenerateSlug()
+		article.GenerateSlug(app.config.maxSlugLength)
 	}
 
 	if input.Article.Description != nil {
@@ -261,13 +789,25 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 		article.Body = *input.Article.Body
 	}
 
+	if input.Article.BodyType != nil {
+		article.BodyType = *input.Article.BodyType
+	}
+
+	if input.Article.CoverImage != nil {
+		article.CoverImage = *input.Article.CoverImage
+	}
+
+	timezone, ok := app.readTimezone(r)
+
 	v := validator.New()
-	if data.ValidateArticle(v, article); !v.Valid() {
+	data.ValidateArticle(v, article)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.modelStore.Articles.Update(article)
+	err = app.modelStore.Articles.Update(r.Context(), article)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -277,10 +817,11 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 		}
 		return
 	}
+	article.Localize(timezone)
 
 	// set location header to point to the new article
 	headers := make(http.Header)
-	headers.Set("Location", "/articles/"+article.Slug)
+	headers.Set("Location", app.articleURL(article.Slug))
 	err = app.writeJSON(w, http.StatusOK, envelope{"article": article}, headers)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -217,6 +229,61 @@ func TestCreateArticleHandler(t *testing.T) {
 			requestBody:            ``,
 			wantResponseStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:                   "tagList as a string",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         requestUrlPath,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Typed", "description": "d", "body": "b", "tagList": "golang"}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"article.tagList\" (expected array, got string)"},
+			},
+		},
+		{
+			name:                   "numeric title",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         requestUrlPath,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": 42, "description": "d", "body": "b"}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"article.title\" (expected string, got number)"},
+			},
+		},
+		{
+			name:                   "boolean body",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         requestUrlPath,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Typed", "description": "d", "body": true}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"article.body\" (expected string, got bool)"},
+			},
+		},
+		{
+			name:                   "article wrapper not an object",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         requestUrlPath,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": "Typed"}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"article\" (expected object, got string)"},
+			},
+		},
+		{
+			name:                   "unknown key inside article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         requestUrlPath,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Typed", "description": "d", "body": "b", "tags": ["golang"]}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains unknown key \"tags\""},
+			},
+		},
 		{
 			name:                   "no auth token",
 			requestMethodType:      http.MethodPost,
@@ -624,6 +691,128 @@ func Test_Favorite_Unfavorite_ArticleHandler_Concurrency(t *testing.T) {
 	assert.Equal(t, 0, response.Article.FavoritesCount)
 }
 
+func Test_Favorite_ArticleHandler_ComputedCountConcurrency(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.computedFavoritesCount = true
+	})
+
+	registerUser(t, ts, "author", "author@example.com", "password123")
+	authorToken := loginUser(t, ts, "author@example.com", "password123")
+	location := createArticle(t, ts, authorToken, "Computed Count Test Article", "Test description", "Test body content", []string{"test"})
+	slug := strings.TrimPrefix(location, "/articles/")
+
+	numUsers := 25
+	userTokens := make([]string, numUsers)
+	for i := range numUsers {
+		username := "computed_user" + strconv.Itoa(i+1)
+		email := username + "@example.com"
+		registerUser(t, ts, username, email, "password123")
+		userTokens[i] = loginUser(t, ts, email, "password123")
+	}
+
+	// All users favorite the article concurrently
+	favoriteErrs := make(chan error, numUsers)
+	for _, token := range userTokens {
+		go func(token string) {
+			headers := map[string]string{"Authorization": "Token " + token}
+			resp, err := ts.executeRequest(http.MethodPost, "/articles/"+slug+"/favorite", "", headers)
+			if err != nil {
+				favoriteErrs <- err
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				favoriteErrs <- fmt.Errorf("unexpected status %d", resp.StatusCode)
+				return
+			}
+			favoriteErrs <- nil
+		}(token)
+	}
+	for range numUsers {
+		require.NoError(t, <-favoriteErrs)
+	}
+
+	resp, err := ts.executeRequest(http.MethodGet, "/articles/"+slug, "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var response getArticleResponse
+	readJsonResponse(t, resp.Body, &response)
+	assert.Equal(t, numUsers, response.Article.FavoritesCount)
+
+	// The counter column is never written, so favorites don't contend on the article row
+	db := ts.openDB(t)
+	var storedCount int
+	err = db.QueryRow(context.Background(), "SELECT favorites_count FROM articles WHERE slug = $1", slug).Scan(&storedCount)
+	require.NoError(t, err)
+	assert.Equal(t, 0, storedCount)
+
+	// Favoriting again and unfavoriting report the computed count
+	headers := map[string]string{"Authorization": "Token " + userTokens[0]}
+	resp, err = ts.executeRequest(http.MethodPost, "/articles/"+slug+"/favorite", "", headers)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	readJsonResponse(t, resp.Body, &response)
+	assert.Equal(t, numUsers, response.Article.FavoritesCount)
+
+	resp, err = ts.executeRequest(http.MethodDelete, "/articles/"+slug+"/favorite", "", headers)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	readJsonResponse(t, resp.Body, &response)
+	assert.Equal(t, numUsers-1, response.Article.FavoritesCount)
+}
+
+// BenchmarkFavoriteHotArticle compares favoriting and unfavoriting a single article from
+// many users in parallel with a maintained counter and with the count computed on read.
+func BenchmarkFavoriteHotArticle(b *testing.B) {
+	for _, computed := range []bool{false, true} {
+		b.Run("computed="+strconv.FormatBool(computed), func(b *testing.B) {
+			ts := newTestServer(b, func(cfg *appConfig) {
+				cfg.computedFavoritesCount = computed
+			})
+			store := ts.app.modelStore
+
+			// Hash the password once and share it, bcrypt would dominate the setup otherwise
+			var template data.User
+			require.NoError(b, template.Password.Set("password123"))
+
+			users := make([]*data.User, 64)
+			for i := range users {
+				user := template
+				user.Username = "bench_user" + strconv.Itoa(i)
+				user.Email = user.Username + "@example.com"
+				require.NoError(b, store.Users.Insert(context.Background(), &user))
+				users[i] = &user
+			}
+
+			article := &data.Article{Title: "Hot Article", Description: "Hot", Body: "Hot", TagList: []string{}}
+			article.GenerateSlug(ts.app.config.maxSlugLength)
+			article, err := store.Articles.InsertAndReturn(context.Background(), article, users[0])
+			require.NoError(b, err)
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				user := users[next.Add(1)%int64(len(users))]
+				for pb.Next() {
+					if _, err := store.Articles.FavoriteBySlug(context.Background(), article.Slug, user.ID, 0); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := store.Articles.UnfavoriteBySlug(context.Background(), article.Slug, user.ID, 0); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
 func TestDeleteArticleHandler(t *testing.T) {
 	t.Parallel()
 
@@ -657,8 +846,130 @@ func TestDeleteArticleHandler(t *testing.T) {
 	getRes, err := ts.executeRequest(http.MethodGet, "/articles/"+slug, "", nil)
 	require.NoError(t, err)
 	defer getRes.Body.Close()
-	assert.Equal(t, http.StatusNotFound, getRes.StatusCode)
+	assert.Equal(t, http.StatusGone, getRes.StatusCode)
+
+}
+
+func TestGetArticleHandler_DeletedSlug(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	headers := map[string]string{"Authorization": "Token " + aliceToken}
+
+	deletedSlug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Short Lived", "Soon gone", "Body", nil), "/articles/")
+	keptSlug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Long Lived", "Still here", "Body", nil), "/articles/")
+
+	res, err := ts.executeRequest(http.MethodDelete, "/articles/"+deletedSlug, "", headers)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "Deleted slug is gone",
+			requestUrlPath:         "/articles/" + deletedSlug,
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusGone,
+			wantResponse: errorResponse{
+				Errors: []string{"the requested resource has been deleted"},
+			},
+		},
+		{
+			name:                   "Slug that never existed is not found",
+			requestUrlPath:         "/articles/never-existed-" + deletedSlug,
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse: errorResponse{
+				Errors: []string{"the requested resource could not be found"},
+			},
+		},
+		{
+			name:                   "Existing article is unaffected",
+			requestUrlPath:         "/articles/" + keptSlug,
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	}
+
+	testHandler(t, ts, testCases...)
+}
+
+func TestArticleSlugRoutes_DeletedSlug(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	headers := map[string]string{"Authorization": "Token " + aliceToken}
+
+	deletedSlug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Short Lived", "Soon gone", "Body", nil), "/articles/")
+	bobSlug := strings.TrimPrefix(createArticle(t, ts, bobToken, "Bob's", "Not Alice's", "Body", nil), "/articles/")
 
+	res, err := ts.executeRequest(http.MethodDelete, "/articles/"+deletedSlug, "", headers)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	routes := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/related", ""},
+		{http.MethodPut, "", `{"article": {"title": "Revived"}}`},
+		{http.MethodDelete, "", ""},
+		{http.MethodPost, "/favorite", ""},
+		{http.MethodDelete, "/favorite", ""},
+		{http.MethodPost, "/comments", `{"comment": {"body": "Too late"}}`},
+		{http.MethodGet, "/comments", ""},
+		{http.MethodGet, "/comments/1", ""},
+		{http.MethodPut, "/comments/1", `{"comment": {"body": "Too late"}}`},
+		{http.MethodDelete, "/comments/1", ""},
+	}
+
+	var testCases []handlerTestcase
+	for _, route := range routes {
+		testCases = append(testCases,
+			handlerTestcase{
+				name:                   route.method + " " + route.path + " on a deleted slug is gone",
+				requestMethodType:      route.method,
+				requestUrlPath:         "/articles/" + deletedSlug + route.path,
+				requestHeader:          headers,
+				requestBody:            route.body,
+				wantResponseStatusCode: http.StatusGone,
+				wantResponse: errorResponse{
+					Errors: []string{"the requested resource has been deleted"},
+				},
+			},
+			handlerTestcase{
+				name:                   route.method + " " + route.path + " on an unknown slug is not found",
+				requestMethodType:      route.method,
+				requestUrlPath:         "/articles/never-existed-" + deletedSlug + route.path,
+				requestHeader:          headers,
+				requestBody:            route.body,
+				wantResponseStatusCode: http.StatusNotFound,
+				wantResponse: errorResponse{
+					Errors: []string{"the requested resource could not be found"},
+				},
+			},
+		)
+	}
+	testCases = append(testCases, handlerTestcase{
+		name:                   "Deleting another user's article is still not found",
+		requestMethodType:      http.MethodDelete,
+		requestUrlPath:         "/articles/" + bobSlug,
+		requestHeader:          headers,
+		wantResponseStatusCode: http.StatusNotFound,
+		wantResponse: errorResponse{
+			Errors: []string{"the requested resource could not be found"},
+		},
+	})
+
+	testHandler(t, ts, testCases...)
 }
 
 func TestUpdateArticleHandler(t *testing.T) {
@@ -873,8 +1184,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -901,8 +1213,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -937,8 +1250,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1002,8 +1316,9 @@ func TestListArticlesHandler(t *testing.T) {
 				require.Equal(t, http.StatusOK, res.StatusCode)
 
 				var response struct {
-					Articles      []data.Article `json:"articles"`
-					ArticlesCount int            `json:"articlesCount"`
+					Articles      []data.Article     `json:"articles"`
+					ArticlesCount int                `json:"articlesCount"`
+					Pagination    paginationMetadata `json:"pagination"`
 				}
 				readJsonResponse(t, res.Body, &response)
 
@@ -1027,8 +1342,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1050,8 +1366,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1078,8 +1395,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1107,8 +1425,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1133,8 +1452,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1188,8 +1508,9 @@ func TestListArticlesHandler(t *testing.T) {
 				require.Equal(t, http.StatusOK, res.StatusCode)
 
 				var response struct {
-					Articles      []data.Article `json:"articles"`
-					ArticlesCount int            `json:"articlesCount"`
+					Articles      []data.Article     `json:"articles"`
+					ArticlesCount int                `json:"articlesCount"`
+					Pagination    paginationMetadata `json:"pagination"`
 				}
 				readJsonResponse(t, res.Body, &response)
 
@@ -1205,6 +1526,64 @@ func TestListArticlesHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("Multiple tags must all match", func(t *testing.T) {
+		testCases := []struct {
+			name        string
+			queryString string
+			wantTitles  []string
+		}{
+			{
+				name:        "two tags",
+				queryString: "/articles?tag=golang&tag=advanced",
+				wantTitles:  []string{"Go Concurrency", "Advanced Golang"},
+			},
+			{
+				name:        "three tags, case-insensitive",
+				queryString: "/articles?tag=Rust&tag=backend&tag=ADVANCED",
+				wantTitles:  []string{"Rust Ownership"},
+			},
+			{
+				name:        "empty values are ignored",
+				queryString: "/articles?tag=&tag=kubernetes",
+				wantTitles:  []string{"Kubernetes Guide"},
+			},
+			{
+				name:        "no article has every tag",
+				queryString: "/articles?tag=golang&tag=react",
+				wantTitles:  []string{},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var response struct {
+					Articles      []data.Article     `json:"articles"`
+					ArticlesCount int                `json:"articlesCount"`
+					Pagination    paginationMetadata `json:"pagination"`
+				}
+				require.NoError(t, json.Unmarshal(getRawBody(t, ts, tc.queryString, nil), &response))
+
+				titles := []string{}
+				for _, article := range response.Articles {
+					titles = append(titles, article.Title)
+				}
+				assert.Equal(t, tc.wantTitles, titles)
+				assert.Equal(t, len(tc.wantTitles), response.ArticlesCount)
+			})
+		}
+	})
+
+	t.Run("Each tag is validated", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/articles?tag=golang&tag=not%20valid", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		var response errorResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{"Tag must contain only alphanumeric characters, hyphens, and underscores"}, response.Errors)
+	})
+
 	t.Run("No results when filter matches nothing", func(t *testing.T) {
 		headers := map[string]string{"Authorization": "Token " + aliceToken}
 		res, err := ts.executeRequest(http.MethodGet, "/articles?tag=nonexistent", "", headers)
@@ -1214,8 +1593,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1232,8 +1612,9 @@ func TestListArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 		require.Len(t, response.Articles, 1)
@@ -1268,6 +1649,11 @@ func TestListArticlesHandler(t *testing.T) {
 				queryString:   "/articles?tag=" + strings.Repeat("a", 51),
 				expectedError: "Tag must not be more than 50 characters",
 			},
+			{
+				name:          "too many tags",
+				queryString:   "/articles?tag=golang" + strings.Repeat("&tag=golang", data.MaxFilterTags),
+				expectedError: fmt.Sprintf("Tag must not be given more than %d times", data.MaxFilterTags),
+			},
 			{
 				name:          "author with special characters",
 				queryString:   "/articles?author=alice@test",
@@ -1359,8 +1745,9 @@ func TestListArticlesHandler_Pagination(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1386,8 +1773,9 @@ func TestListArticlesHandler_Pagination(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1421,17 +1809,17 @@ func TestArticleStore_GetIDBySlug(t *testing.T) {
 	slug := articleLocation[10:] // Remove "/articles/" prefix
 
 	// Test: Get article ID by slug
-	articleID, err := ts.app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := ts.app.modelStore.Articles.GetIDBySlug(context.Background(), slug)
 	require.NoError(t, err)
 	require.NotZero(t, articleID, "Article ID should not be zero")
 
 	// Verify it's the correct ID by getting the full article
-	fullArticle, err := ts.app.modelStore.Articles.GetBySlug(slug, data.AnonymousUser)
+	fullArticle, err := ts.app.modelStore.Articles.GetBySlug(context.Background(), slug, data.AnonymousUser)
 	require.NoError(t, err)
 	require.Equal(t, fullArticle.ID, articleID, "IDs should match")
 
 	// Test: Non-existent slug
-	nonExistentID, err := ts.app.modelStore.Articles.GetIDBySlug("non-existent-slug-12345")
+	nonExistentID, err := ts.app.modelStore.Articles.GetIDBySlug(context.Background(), "non-existent-slug-12345")
 	require.Error(t, err)
 	require.Equal(t, data.ErrRecordNotFound, err, "Should return ErrRecordNotFound for non-existent slug")
 	require.Zero(t, nonExistentID, "ID should be zero for non-existent article")
@@ -1544,8 +1932,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1573,8 +1962,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1598,8 +1988,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1627,8 +2018,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 
 		var response struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &response)
 
@@ -1648,8 +2040,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		require.Equal(t, http.StatusOK, res2.StatusCode)
 
 		var response2 struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res2.Body, &response2)
 
@@ -1671,8 +2064,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		defer res.Body.Close()
 
 		var beforeFavorite struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res.Body, &beforeFavorite)
 
@@ -1696,8 +2090,9 @@ func TestFeedArticlesHandler(t *testing.T) {
 		defer res2.Body.Close()
 
 		var afterFavorite struct {
-			Articles      []data.Article `json:"articles"`
-			ArticlesCount int            `json:"articlesCount"`
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
 		}
 		readJsonResponse(t, res2.Body, &afterFavorite)
 
@@ -1711,3 +2106,2390 @@ func TestFeedArticlesHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestListArticlesHandler_SparseFieldsets(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	createArticle(t, ts, aliceToken, "Sparse Article", "Sparse description", "Sparse body", []string{"golang"})
+
+	t.Run("Only requested fields are returned", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/articles?fields=slug,title,favoritesCount", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []map[string]any   `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		readJsonResponse(t, res.Body, &response)
+
+		require.Len(t, response.Articles, 1)
+		assert.Equal(t, 1, response.ArticlesCount)
+
+		article := response.Articles[0]
+		assert.Len(t, article, 3)
+		assert.Equal(t, "Sparse Article", article["title"])
+		assert.Contains(t, article, "slug")
+		assert.Contains(t, article, "favoritesCount")
+		assert.NotContains(t, article, "description")
+		assert.NotContains(t, article, "tagList")
+		assert.NotContains(t, article, "author")
+	})
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "Unknown field is rejected",
+		requestMethodType:      http.MethodGet,
+		requestUrlPath:         "/articles?fields=slug,body",
+		wantResponseStatusCode: http.StatusUnprocessableEntity,
+		wantResponse: errorResponse{
+			Errors: []string{`Fields contains unknown field "body"`},
+		},
+	})
+}
+
+func TestCreateArticleHandler_MaxArticlesPerUser(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxArticles = 2
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	// Creating up to the quota succeeds
+	createArticle(t, ts, aliceToken, "First", "First article", "Body", nil)
+	createArticle(t, ts, aliceToken, "Second", "Second article", "Body", nil)
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Creating beyond the quota fails",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"Third","description":"Third article","body":"Body"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"cannot create more than 2 articles"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Quota is tracked per user",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"Bob's first","description":"Bob article","body":"Body"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusCreated,
+		},
+	)
+}
+
+func TestCreateArticleHandler_MaxArticlesPerUserConcurrent(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxArticles = 2
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	// Create several articles at once; the quota must hold even though the requests race
+	const attempts = 6
+	statuses := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"article":{"title":"Article %d","description":"Racing","body":"Body"}}`, i)
+			res, err := ts.executeRequest(http.MethodPost, "/articles", body, authHeader)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			res.Body.Close() //nolint: errcheck
+			statuses <- res.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 2, http.StatusUnprocessableEntity: attempts - 2}, counts)
+
+	var owned int
+	err := ts.openDB(t).QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM articles a JOIN users u ON a.author_id = u.id WHERE u.username = 'alice'").Scan(&owned)
+	require.NoError(t, err)
+	assert.Equal(t, 2, owned)
+}
+
+func TestEmptyCollectionsMarshalAsArrays(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	// Create an article without a tagList at all
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article":{"title":"No Tags","description":"No tags here","body":"Body"}}`, authHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	createBody, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	location := res.Header.Get("Location")
+
+	testCases := []struct {
+		name     string
+		body     func() []byte
+		wantJSON string
+	}{
+		{
+			name:     "Created article tagList",
+			body:     func() []byte { return createBody },
+			wantJSON: `"tagList":[]`,
+		},
+		{
+			name:     "Fetched article tagList",
+			body:     func() []byte { return getRawBody(t, ts, location, nil) },
+			wantJSON: `"tagList":[]`,
+		},
+		{
+			name:     "Listed article tagList",
+			body:     func() []byte { return getRawBody(t, ts, "/articles", nil) },
+			wantJSON: `"tagList":[]`,
+		},
+		{
+			name:     "Comments on an article without comments",
+			body:     func() []byte { return getRawBody(t, ts, location+"/comments", nil) },
+			wantJSON: `"comments":[]`,
+		},
+		{
+			name:     "Feed of a user following no one",
+			body:     func() []byte { return getRawBody(t, ts, "/articles/feed", authHeader) },
+			wantJSON: `"articles":[]`,
+		},
+		{
+			name:     "Articles filtered to nothing",
+			body:     func() []byte { return getRawBody(t, ts, "/articles?tag=nothing", nil) },
+			wantJSON: `"articles":[]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := string(tc.body())
+			assert.Contains(t, body, tc.wantJSON)
+			assert.NotContains(t, body, "null")
+		})
+	}
+}
+
+// getRawBody performs a GET request and returns the raw response body.
+func getRawBody(t *testing.T, ts *testServer, urlPath string, headers map[string]string) []byte {
+	t.Helper()
+
+	res, err := ts.executeRequest(http.MethodGet, urlPath, "", headers)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return body
+}
+
+func TestCreateArticleHandler_MissingTagListStoresEmptyArray(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article":{"title":"No Tags","description":"No tags here","body":"Body"}}`,
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	slug := strings.TrimPrefix(res.Header.Get("Location"), "/articles/")
+
+	var isNull bool
+	var tagList []string
+	err = ts.openDB(t).QueryRow(context.Background(),
+		"SELECT tag_list IS NULL, tag_list FROM articles WHERE slug = $1", slug).Scan(&isNull, &tagList)
+	require.NoError(t, err)
+
+	assert.False(t, isNull, "tag_list should be an empty array, not NULL")
+	assert.NotNil(t, tagList)
+	assert.Empty(t, tagList)
+}
+
+func TestFavoriteArticleHandler_ListReadAfterWrite(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	slug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Read After Write", "Consistency", "Body", nil), "/articles/")
+
+	listArticle := func(t *testing.T) data.Article {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodGet, "/articles?author=alice", "", bobHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		require.Len(t, response.Articles, 1)
+		return response.Articles[0]
+	}
+
+	// Favorite and immediately list: the list must reflect the new state
+	favoriteArticleHelper(t, ts, bobToken, slug)
+	article := listArticle(t)
+	assert.True(t, article.Favorited)
+	assert.Equal(t, 1, article.FavoritesCount)
+
+	// Unfavorite and immediately list again
+	res, err := ts.executeRequest(http.MethodDelete, "/articles/"+slug+"/favorite", "", bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	article = listArticle(t)
+	assert.False(t, article.Favorited)
+	assert.Equal(t, 0, article.FavoritesCount)
+}
+
+func TestArticleResponses_MaxResponseTags(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxResponseTags = 2
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	slug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Many Tags", "Lots of tags", "Body",
+		[]string{"gamma", "alpha", "beta"}), "/articles/")
+
+	t.Run("Get truncates the sorted tag list", func(t *testing.T) {
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/"+slug, nil), &response))
+		assert.Equal(t, []string{"alpha", "beta"}, response.Article.TagList)
+	})
+
+	t.Run("List truncates the sorted tag list", func(t *testing.T) {
+		var response struct {
+			Articles []data.Article `json:"articles"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles", nil), &response))
+		require.Len(t, response.Articles, 1)
+		assert.Equal(t, []string{"alpha", "beta"}, response.Articles[0].TagList)
+	})
+
+	t.Run("Stored tags are left untouched", func(t *testing.T) {
+		var tagList []string
+		err := ts.openDB(t).QueryRow(context.Background(),
+			"SELECT tag_list FROM articles WHERE slug = $1", slug).Scan(&tagList)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alpha", "beta", "gamma"}, tagList)
+	})
+}
+
+func TestArticleResponses_UnlimitedResponseTags(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxResponseTags = 0
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	location := createArticle(t, ts, aliceToken, "Many Tags", "Lots of tags", "Body", []string{"gamma", "alpha", "beta"})
+
+	var response getArticleResponse
+	require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, nil), &response))
+	assert.Equal(t, []string{"alpha", "beta", "gamma"}, response.Article.TagList)
+}
+
+func TestDeleteArticleHandler_WritesAuditLog(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	slug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Audited", "Audited article", "Body", nil), "/articles/")
+
+	db := ts.openDB(t)
+	var aliceID, articleID int64
+	err := db.QueryRow(context.Background(),
+		"SELECT author_id, id FROM articles WHERE slug = $1", slug).Scan(&aliceID, &articleID)
+	require.NoError(t, err)
+
+	res, err := ts.executeRequest(http.MethodDelete, "/articles/"+slug, "",
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	var actorID, targetID int64
+	var targetType, targetSlug string
+	var createdAt time.Time
+	err = db.QueryRow(context.Background(), `
+		SELECT actor_id, target_type, target_id, target_slug, created_at
+		FROM audit_log
+		WHERE action = 'delete'`).Scan(&actorID, &targetType, &targetID, &targetSlug, &createdAt)
+	require.NoError(t, err)
+
+	assert.Equal(t, aliceID, actorID)
+	assert.Equal(t, "article", targetType)
+	assert.Equal(t, articleID, targetID)
+	assert.Equal(t, slug, targetSlug)
+	assert.False(t, createdAt.IsZero())
+
+	// The creation was recorded as well
+	var creates int
+	err = db.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM audit_log WHERE action = 'create' AND target_id = $1", articleID).Scan(&creates)
+	require.NoError(t, err)
+	assert.Equal(t, 1, creates)
+}
+
+func TestTrendingArticlesHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	var fans []string
+	for _, name := range []string{"bob", "carol", "dave"} {
+		registerUser(t, ts, name, name+"@example.com", "password123")
+		fans = append(fans, loginUser(t, ts, name+"@example.com", "password123"))
+	}
+
+	slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+	one := slugOf(createArticle(t, ts, aliceToken, "One Fan", "One favorite", "Body", nil))
+	three := slugOf(createArticle(t, ts, aliceToken, "Three Fans", "Three favorites", "Body", nil))
+	two := slugOf(createArticle(t, ts, aliceToken, "Two Fans", "Two favorites", "Body", nil))
+	createArticle(t, ts, aliceToken, "No Fans", "No favorites", "Body", nil)
+
+	favoriteArticleHelper(t, ts, fans[0], one)
+	for _, token := range fans {
+		favoriteArticleHelper(t, ts, token, three)
+	}
+	favoriteArticleHelper(t, ts, fans[0], two)
+	favoriteArticleHelper(t, ts, fans[1], two)
+
+	leaderboard := func(t *testing.T, query string) []string {
+		t.Helper()
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/trending"+query, nil), &response))
+		assert.Equal(t, len(response.Articles), response.ArticlesCount)
+
+		slugs := make([]string, len(response.Articles))
+		for i, article := range response.Articles {
+			slugs[i] = article.Slug
+		}
+		return slugs
+	}
+
+	t.Run("Ranked by all-time favorites", func(t *testing.T) {
+		assert.Equal(t, []string{three, two, one}, leaderboard(t, ""))
+	})
+
+	t.Run("Limit caps the leaderboard", func(t *testing.T) {
+		assert.Equal(t, []string{three, two}, leaderboard(t, "?limit=2"))
+	})
+
+	t.Run("Window only counts recent favorites", func(t *testing.T) {
+		// Age the most favorited article's favorites beyond the window
+		_, err := ts.openDB(t).Exec(context.Background(), `
+			UPDATE favorites SET created_at = created_at - INTERVAL '30 days'
+			WHERE article_id = (SELECT id FROM articles WHERE slug = $1)`, three)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{two, one}, leaderboard(t, "?days=7"))
+		assert.Equal(t, []string{three, two, one}, leaderboard(t, "?days=60"))
+	})
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "Window out of range",
+		requestMethodType:      http.MethodGet,
+		requestUrlPath:         "/articles/trending?days=366",
+		wantResponseStatusCode: http.StatusUnprocessableEntity,
+		wantResponse: errorResponse{
+			Errors: []string{"Days must be between 0 and 365"},
+		},
+	})
+}
+
+func TestListArticlesHandler_PaginationMetadata(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	followUser(t, ts, bobToken, "alice")
+
+	for i := range 5 {
+		createArticle(t, ts, aliceToken, fmt.Sprintf("Article %d", i), "Paged", "Body", nil)
+	}
+
+	testCases := []struct {
+		name string
+		path string
+		want paginationMetadata
+	}{
+		{"List first page", "/articles?limit=2", paginationMetadata{Limit: 2, Offset: 0, HasMore: true}},
+		{"List middle page", "/articles?limit=2&offset=2", paginationMetadata{Limit: 2, Offset: 2, HasMore: true}},
+		{"List last page", "/articles?limit=2&offset=4", paginationMetadata{Limit: 2, Offset: 4, HasMore: false}},
+		{"List exactly filled page", "/articles?limit=5", paginationMetadata{Limit: 5, Offset: 0, HasMore: false}},
+		{"Feed non-final page", "/articles/feed?limit=3", paginationMetadata{Limit: 3, Offset: 0, HasMore: true}},
+		{"Feed last page", "/articles/feed?limit=3&offset=3", paginationMetadata{Limit: 3, Offset: 3, HasMore: false}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var response struct {
+				ArticlesCount int                `json:"articlesCount"`
+				Pagination    paginationMetadata `json:"pagination"`
+			}
+			body := getRawBody(t, ts, tc.path, map[string]string{"Authorization": "Token " + bobToken})
+			require.NoError(t, json.Unmarshal(body, &response))
+
+			assert.Equal(t, 5, response.ArticlesCount)
+			assert.Equal(t, tc.want, response.Pagination)
+		})
+	}
+}
+
+func TestBatchArticlesHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	first := strings.TrimPrefix(createArticle(t, ts, aliceToken, "First", "First article", "Body", []string{"go"}), "/articles/")
+	second := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Second", "Second article", "Body", nil), "/articles/")
+	favoriteArticleHelper(t, ts, bobToken, second)
+	followUser(t, ts, bobToken, "alice")
+
+	batch := func(t *testing.T, body string, headers map[string]string) []data.Article {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, "/articles/batch", body, headers)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, len(response.Articles), response.ArticlesCount)
+		return response.Articles
+	}
+
+	t.Run("Existing and missing slugs in requested order", func(t *testing.T) {
+		articles := batch(t, `{"slugs":["`+second+`","no-such-article","`+first+`","`+second+`"]}`,
+			map[string]string{"Authorization": "Token " + bobToken})
+		require.Len(t, articles, 2)
+
+		assert.Equal(t, second, articles[0].Slug)
+		assert.True(t, articles[0].Favorited)
+		assert.Equal(t, 1, articles[0].FavoritesCount)
+		assert.True(t, articles[0].Author.Following)
+
+		assert.Equal(t, first, articles[1].Slug)
+		assert.False(t, articles[1].Favorited)
+		assert.Equal(t, []string{"go"}, articles[1].TagList)
+	})
+
+	t.Run("Anonymous viewer", func(t *testing.T) {
+		articles := batch(t, `{"slugs":["`+second+`"]}`, nil)
+		require.Len(t, articles, 1)
+		assert.False(t, articles[0].Favorited)
+		assert.False(t, articles[0].Author.Following)
+	})
+
+	t.Run("Only missing slugs", func(t *testing.T) {
+		assert.Empty(t, batch(t, `{"slugs":["no-such-article"]}`, nil))
+	})
+
+	tooMany := make([]string, ts.app.config.maxBatchSlugs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`"slug-%d"`, i)
+	}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "No slugs",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/batch",
+			requestBody:            `{"slugs":[]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"slugs must be provided"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Too many slugs",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/batch",
+			requestBody:            `{"slugs":[` + strings.Join(tooMany, ",") + `]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"slugs must not contain more than 100 entries"},
+			},
+		},
+	)
+}
+
+func TestFavoriteArticleHandler_SelfFavoritePolicy(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, forbid bool) (*testServer, string, string) {
+		t.Helper()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.forbidSelfFavorite = forbid
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+		slug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Own Article", "Mine", "Body", nil), "/articles/")
+		return ts, aliceToken, slug
+	}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+		ts, aliceToken, slug := setup(t, true)
+		registerUser(t, ts, "bob", "bob@example.com", "password123")
+		bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+		testHandler(t, ts,
+			handlerTestcase{
+				name:                   "Author cannot favorite their own article",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/articles/" + slug + "/favorite",
+				requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse: errorResponse{
+					Errors: []string{"cannot favorite your own article"},
+				},
+			},
+			handlerTestcase{
+				name:                   "Other users can still favorite it",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/articles/" + slug + "/favorite",
+				requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+				wantResponseStatusCode: http.StatusOK,
+			},
+		)
+
+		// Only Bob's favorite was counted
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/"+slug, nil), &response))
+		assert.Equal(t, 1, response.Article.FavoritesCount)
+	})
+
+	t.Run("Allowed by default", func(t *testing.T) {
+		t.Parallel()
+		ts, aliceToken, slug := setup(t, false)
+
+		res, err := ts.executeRequest(http.MethodPost, "/articles/"+slug+"/favorite", "",
+			map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.True(t, response.Article.Favorited)
+		assert.Equal(t, 1, response.Article.FavoritesCount)
+	})
+}
+
+func TestArticleBodyType(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	postArticle := func(t *testing.T, title, body, bodyType string) string {
+		t.Helper()
+
+		input := map[string]any{"title": title, "description": "Rendering", "body": body}
+		if bodyType != "" {
+			input["bodyType"] = bodyType
+		}
+		js, err := json.Marshal(map[string]any{"article": input})
+		require.NoError(t, err)
+
+		res, err := ts.executeRequest(http.MethodPost, "/articles", string(js), authHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		return strings.TrimPrefix(res.Header.Get("Location"), "/articles/")
+	}
+
+	getArticle := func(t *testing.T, path string) data.Article {
+		t.Helper()
+
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, path, nil), &response))
+		return response.Article
+	}
+
+	malicious := "# Hello\n\n<script>alert('xss')</script> [click](javascript:alert(1)) <img src=x onerror=alert(1)>"
+
+	t.Run("Markdown is the default and is not rendered unless asked", func(t *testing.T) {
+		slug := postArticle(t, "Markdown", malicious, "")
+
+		article := getArticle(t, "/articles/"+slug)
+		assert.Equal(t, "markdown", article.BodyType)
+		assert.Equal(t, malicious, article.Body)
+		assert.Empty(t, article.BodyHTML)
+	})
+
+	t.Run("Markdown is rendered to sanitized HTML", func(t *testing.T) {
+		slug := postArticle(t, "Rendered", malicious, "")
+
+		article := getArticle(t, "/articles/"+slug+"?render=html")
+		assert.Equal(t, malicious, article.Body, "raw body must be kept intact")
+		assert.Contains(t, article.BodyHTML, "<h1>Hello</h1>")
+		assert.NotContains(t, article.BodyHTML, "<script")
+		assert.NotContains(t, article.BodyHTML, "javascript:")
+		assert.NotContains(t, article.BodyHTML, "onerror")
+	})
+
+	t.Run("Plain bodies are escaped rather than rendered", func(t *testing.T) {
+		slug := postArticle(t, "Plain", "# Not a heading <b>", "plain")
+
+		article := getArticle(t, "/articles/"+slug+"?render=html")
+		assert.Equal(t, "plain", article.BodyType)
+		assert.Equal(t, "<p># Not a heading &lt;b&gt;</p>", article.BodyHTML)
+	})
+
+	t.Run("Body type can be updated", func(t *testing.T) {
+		slug := postArticle(t, "Switch", "*text*", "")
+
+		res, err := ts.executeRequest(http.MethodPut, "/articles/"+slug, `{"article":{"bodyType":"plain"}}`, authHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		assert.Equal(t, "plain", getArticle(t, "/articles/"+slug).BodyType)
+	})
+
+	slug := postArticle(t, "Validation", "Body", "")
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Unknown body type",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"Bad","description":"Bad type","body":"Body","bodyType":"html"}}`,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"BodyType must be one of markdown, plain"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Unknown render format",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "?render=pdf",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"render must be html"},
+			},
+		},
+	)
+}
+
+func TestArticleCoverImage(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	getArticle := func(t *testing.T, location string) data.Article {
+		t.Helper()
+
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, nil), &response))
+		return response.Article
+	}
+
+	t.Run("Cover image is optional", func(t *testing.T) {
+		location := createArticle(t, ts, aliceToken, "No cover", "Plain", "Body", nil)
+
+		var response map[string]map[string]any
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, nil), &response))
+		assert.Equal(t, "", response["article"]["coverImage"])
+	})
+
+	t.Run("Cover image round-trips through get and list", func(t *testing.T) {
+		cover := "https://example.com/cover.png"
+		res, err := ts.executeRequest(http.MethodPost, "/articles",
+			`{"article":{"title":"Covered","description":"With a cover","body":"Body","coverImage":"`+cover+`"}}`, authHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var created getArticleResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+		assert.Equal(t, cover, created.Article.CoverImage)
+
+		location := res.Header.Get("Location")
+		assert.Equal(t, cover, getArticle(t, location).CoverImage)
+
+		var list struct {
+			Articles []data.Article `json:"articles"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles?author=alice", nil), &list))
+		covers := map[string]string{}
+		for _, article := range list.Articles {
+			covers[article.Title] = article.CoverImage
+		}
+		assert.Equal(t, cover, covers["Covered"])
+	})
+
+	t.Run("Cover image can be updated and cleared", func(t *testing.T) {
+		location := createArticle(t, ts, aliceToken, "Recover", "Changing covers", "Body", nil)
+
+		res, err := ts.executeRequest(http.MethodPut, location, `{"article":{"coverImage":"https://example.com/new.png"}}`, authHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var updated getArticleResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&updated))
+		assert.Equal(t, "https://example.com/new.png", getArticle(t, "/articles/"+updated.Article.Slug).CoverImage)
+
+		res, err = ts.executeRequest(http.MethodPut, "/articles/"+updated.Article.Slug, `{"article":{"coverImage":""}}`, authHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Empty(t, getArticle(t, "/articles/"+updated.Article.Slug).CoverImage)
+	})
+
+	location := createArticle(t, ts, aliceToken, "Validation", "Bad covers", "Body", nil)
+	invalid := errorResponse{Errors: []string{"CoverImage must be an https URL"}}
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Insecure cover image on create",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"Bad","description":"Bad cover","body":"Body","coverImage":"http://example.com/cover.png"}}`,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Malformed cover image on update",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         location,
+			requestBody:            `{"article":{"coverImage":"not a url"}}`,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+	)
+}
+
+func TestTimezoneParameter(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	location := createArticle(t, ts, aliceToken, "Zoned", "Local times", "Body", nil)
+	createCommentHelper(t, ts, aliceToken, location, "A comment")
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// assertLocal checks the local timestamp is the UTC one shown with New York's offset
+	assertLocal := func(t *testing.T, utc time.Time, local *time.Time) {
+		t.Helper()
+
+		require.NotNil(t, local)
+		assert.True(t, utc.Equal(*local), "local timestamp must be the same instant")
+		assert.Equal(t, time.UTC, utc.Location(), "UTC timestamp must be kept")
+		_, wantOffset := utc.In(newYork).Zone()
+		_, offset := local.Zone()
+		assert.Equal(t, wantOffset, offset)
+	}
+
+	t.Run("Timestamps are only localized when asked", func(t *testing.T) {
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, nil), &response))
+		assert.Nil(t, response.Article.CreatedAtLocal)
+		assert.Nil(t, response.Article.UpdatedAtLocal)
+	})
+
+	t.Run("Article", func(t *testing.T) {
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location+"?tz=America/New_York", nil), &response))
+		assertLocal(t, response.Article.CreatedAt, response.Article.CreatedAtLocal)
+		assertLocal(t, response.Article.UpdatedAt, response.Article.UpdatedAtLocal)
+	})
+
+	t.Run("Article list", func(t *testing.T) {
+		var response struct {
+			Articles []data.Article `json:"articles"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles?tz=America/New_York", nil), &response))
+		require.Len(t, response.Articles, 1)
+		assertLocal(t, response.Articles[0].CreatedAt, response.Articles[0].CreatedAtLocal)
+	})
+
+	t.Run("Comments", func(t *testing.T) {
+		var response struct {
+			Comments []data.Comment `json:"comments"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location+"/comments?tz=America/New_York", nil), &response))
+		require.Len(t, response.Comments, 1)
+		assertLocal(t, response.Comments[0].CreatedAt, response.Comments[0].CreatedAtLocal)
+		assertLocal(t, response.Comments[0].UpdatedAt, response.Comments[0].UpdatedAtLocal)
+	})
+
+	t.Run("Sparse fieldset", func(t *testing.T) {
+		var response struct {
+			Articles []map[string]json.RawMessage `json:"articles"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles?fields=slug,createdAt&tz=America/New_York", nil), &response))
+		require.Len(t, response.Articles, 1)
+		assert.Contains(t, response.Articles[0], "createdAtLocal")
+		assert.NotContains(t, response.Articles[0], "updatedAtLocal")
+	})
+
+	t.Run("Article batch", func(t *testing.T) {
+		slug := strings.TrimPrefix(location, "/articles/")
+		res, err := ts.executeRequest(http.MethodPost, "/articles/batch?tz=America/New_York", `{"slugs":["`+slug+`"]}`, nil)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var response struct {
+			Articles []data.Article `json:"articles"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		require.Len(t, response.Articles, 1)
+		assertLocal(t, response.Articles[0].CreatedAt, response.Articles[0].CreatedAtLocal)
+	})
+
+	t.Run("Created comment", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodPost, location+"/comments?tz=America/New_York",
+			`{"comment":{"body":"Another comment"}}`, map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var response struct {
+			Comment data.Comment `json:"comment"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assertLocal(t, response.Comment.CreatedAt, response.Comment.CreatedAtLocal)
+	})
+
+	invalid := errorResponse{Errors: []string{"tz must be a known time zone, such as America/New_York"}}
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Unknown time zone on an article",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "?tz=Mars/Olympus_Mons",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on the article list",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles?tz=Local",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on comments",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "/comments?tz=New_York",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on trending articles",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/trending?tz=Mars/Olympus_Mons",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on related articles",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "/related?tz=Mars/Olympus_Mons",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on an update is rejected before saving",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         location + "?tz=Mars/Olympus_Mons",
+			requestBody:            `{"article":{"body":"Changed"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+		handlerTestcase{
+			name:                   "Unknown time zone on a new comment",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         location + "/comments?tz=Mars/Olympus_Mons",
+			requestBody:            `{"comment":{"body":"Rejected"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           invalid,
+		},
+	)
+}
+
+func TestDefaultTimezone(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.defaultTimezone = "Asia/Kolkata"
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	location := createArticle(t, ts, aliceToken, "Zoned", "Local times", "Body", nil)
+
+	offsetOf := func(t *testing.T, path string) int {
+		t.Helper()
+
+		var response getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, path, nil), &response))
+		require.NotNil(t, response.Article.CreatedAtLocal)
+		_, offset := response.Article.CreatedAtLocal.Zone()
+		return offset
+	}
+
+	assert.Equal(t, 5*60*60+30*60, offsetOf(t, location), "default time zone is used without tz")
+	assert.Equal(t, 0, offsetOf(t, location+"?tz=UTC"), "tz overrides the default")
+}
+
+func TestCommentedArticlesHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+	first := createArticle(t, ts, aliceToken, "First Article", "First", "Body", nil)
+	second := createArticle(t, ts, aliceToken, "Second Article", "Second", "Body", nil)
+	third := createArticle(t, ts, aliceToken, "Third Article", "Third", "Body", nil)
+	createArticle(t, ts, aliceToken, "Uncommented Article", "Nobody commented", "Body", nil)
+
+	createCommentHelper(t, ts, bobToken, first, "First!")
+	createCommentHelper(t, ts, bobToken, second, "Nice")
+	createCommentHelper(t, ts, bobToken, third, "Thanks")
+	createCommentHelper(t, ts, bobToken, first, "Coming back to this one")
+	createCommentHelper(t, ts, aliceToken, second, "Alice's comments don't count for bob")
+	favoriteArticleHelper(t, ts, bobToken, slugOf(third))
+
+	type commentedResponse struct {
+		Articles      []data.Article     `json:"articles"`
+		ArticlesCount int                `json:"articlesCount"`
+		Pagination    paginationMetadata `json:"pagination"`
+	}
+
+	commented := func(t *testing.T, path string, headers map[string]string) commentedResponse {
+		t.Helper()
+
+		var response commentedResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, path, headers), &response))
+		return response
+	}
+
+	slugs := func(articles []data.Article) []string {
+		result := make([]string, len(articles))
+		for i, article := range articles {
+			result[i] = article.Slug
+		}
+		return result
+	}
+
+	t.Run("Distinct articles ordered by most recent comment", func(t *testing.T) {
+		response := commented(t, "/profiles/bob/commented-articles", nil)
+		assert.Equal(t, []string{slugOf(first), slugOf(third), slugOf(second)}, slugs(response.Articles))
+		assert.Equal(t, 3, response.ArticlesCount)
+		for _, article := range response.Articles {
+			assert.False(t, article.Favorited)
+			assert.False(t, article.Author.Following)
+		}
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		response := commented(t, "/profiles/bob/commented-articles?limit=2&offset=1", nil)
+		assert.Equal(t, []string{slugOf(third), slugOf(second)}, slugs(response.Articles))
+		assert.Equal(t, 3, response.ArticlesCount)
+		assert.Equal(t, paginationMetadata{Limit: 2, Offset: 1, HasMore: false}, response.Pagination)
+	})
+
+	t.Run("Enriched for the viewer", func(t *testing.T) {
+		followUser(t, ts, bobToken, "alice")
+
+		response := commented(t, "/profiles/bob/commented-articles", map[string]string{"Authorization": "Token " + bobToken})
+		require.Len(t, response.Articles, 3)
+		for _, article := range response.Articles {
+			assert.Equal(t, article.Slug == slugOf(third), article.Favorited, article.Slug)
+			assert.True(t, article.Author.Following)
+		}
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/profiles/nobody/commented-articles", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestFeedArticlesHandler_EngagementAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	followUser(t, ts, bobToken, "alice")
+
+	slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+	stale := slugOf(createArticle(t, ts, aliceToken, "Stale Hit", "Popular days ago", "Body", nil))
+	popular := slugOf(createArticle(t, ts, aliceToken, "Recent Hit", "Popular hours ago", "Body", nil))
+	fresh := slugOf(createArticle(t, ts, aliceToken, "Fresh", "Just posted", "Body", nil))
+
+	// Seed ages and favorite counts directly so the scores are deterministic
+	db := ts.openDB(t)
+	seed := func(slug string, age time.Duration, favorites int) {
+		_, err := db.Exec(context.Background(), `
+			UPDATE articles SET created_at = (NOW() AT TIME ZONE 'UTC') - make_interval(secs => $2), favorites_count = $3
+			WHERE slug = $1`, slug, age.Seconds(), favorites)
+		require.NoError(t, err)
+	}
+	seed(stale, 72*time.Hour, 5)
+	seed(popular, 2*time.Hour, 5)
+	seed(fresh, time.Minute, 0)
+
+	feed := func(t *testing.T, query string) []string {
+		t.Helper()
+
+		var response struct {
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/feed"+query, map[string]string{"Authorization": "Token " + bobToken}), &response))
+		assert.Equal(t, 3, response.ArticlesCount)
+
+		slugs := make([]string, len(response.Articles))
+		for i, article := range response.Articles {
+			slugs[i] = article.Slug
+		}
+		return slugs
+	}
+
+	t.Run("Chronological by default", func(t *testing.T) {
+		assert.Equal(t, []string{fresh, popular, stale}, feed(t, ""))
+		assert.Equal(t, []string{fresh, popular, stale}, feed(t, "?algorithm=chronological"))
+	})
+
+	t.Run("Engagement favors recent popular articles", func(t *testing.T) {
+		assert.Equal(t, []string{popular, fresh, stale}, feed(t, "?algorithm=engagement"))
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/articles/feed?algorithm=random", "", map[string]string{"Authorization": "Token " + bobToken})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+}
+
+func TestFeedArticlesHandler_EmptyHint(t *testing.T) {
+	t.Parallel()
+
+	type feedMeta struct {
+		IsEmpty bool   `json:"isEmpty"`
+		Reason  string `json:"reason"`
+	}
+	type feedResponse struct {
+		Articles      []data.Article     `json:"articles"`
+		ArticlesCount int                `json:"articlesCount"`
+		Pagination    paginationMetadata `json:"pagination"`
+		Meta          *feedMeta          `json:"meta"`
+	}
+
+	newServer := func(t *testing.T, enabled bool) (*testServer, string) {
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.feedEmptyHint = enabled
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		registerUser(t, ts, "bob", "bob@example.com", "password123")
+		return ts, loginUser(t, ts, "bob@example.com", "password123")
+	}
+
+	feed := func(t *testing.T, ts *testServer, token string) feedResponse {
+		t.Helper()
+
+		var response feedResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/feed", map[string]string{"Authorization": "Token " + token}), &response))
+		return response
+	}
+
+	t.Run("Not following anyone", func(t *testing.T) {
+		t.Parallel()
+
+		ts, bobToken := newServer(t, true)
+
+		response := feed(t, ts, bobToken)
+		assert.Empty(t, response.Articles)
+		require.NotNil(t, response.Meta)
+		assert.Equal(t, feedMeta{IsEmpty: true, Reason: "not_following_anyone"}, *response.Meta)
+	})
+
+	t.Run("No articles from followed users", func(t *testing.T) {
+		t.Parallel()
+
+		ts, bobToken := newServer(t, true)
+		followUser(t, ts, bobToken, "alice")
+
+		response := feed(t, ts, bobToken)
+		assert.Empty(t, response.Articles)
+		require.NotNil(t, response.Meta)
+		assert.Equal(t, feedMeta{IsEmpty: true, Reason: "no_articles_from_follows"}, *response.Meta)
+	})
+
+	t.Run("Non-empty feed has no meta", func(t *testing.T) {
+		t.Parallel()
+
+		ts, bobToken := newServer(t, true)
+		followUser(t, ts, bobToken, "alice")
+		aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+		createArticle(t, ts, aliceToken, "Hello", "First post", "Body", nil)
+
+		response := feed(t, ts, bobToken)
+		assert.Len(t, response.Articles, 1)
+		assert.Nil(t, response.Meta)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		ts, bobToken := newServer(t, false)
+
+		response := feed(t, ts, bobToken)
+		assert.Empty(t, response.Articles)
+		assert.Nil(t, response.Meta)
+	})
+}
+
+func TestListAndFeedHandlers_DefaultSort(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		defaultSort defaultSortConfig
+	}{
+		{name: "Recent defaults", defaultSort: defaultSortConfig{list: data.ArticleSortRecent, feed: data.ArticleSortRecent}},
+		{name: "Trending list, recent feed", defaultSort: defaultSortConfig{list: data.ArticleSortTrending, feed: data.ArticleSortRecent}},
+		{name: "Recent list, trending feed", defaultSort: defaultSortConfig{list: data.ArticleSortRecent, feed: data.ArticleSortTrending}},
+		{name: "Oldest list, recent feed", defaultSort: defaultSortConfig{list: data.ArticleSortOldest, feed: data.ArticleSortRecent}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := newTestServer(t, func(cfg *appConfig) {
+				cfg.defaultSort = tc.defaultSort
+			})
+			registerUser(t, ts, "alice", "alice@example.com", "password123")
+			registerUser(t, ts, "bob", "bob@example.com", "password123")
+			aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+			bobToken := loginUser(t, ts, "bob@example.com", "password123")
+			followUser(t, ts, bobToken, "alice")
+
+			// The middle article is the favorited one, so all the orderings differ
+			slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+			old := slugOf(createArticle(t, ts, aliceToken, "Old", "Posted first", "Body", nil))
+			popular := slugOf(createArticle(t, ts, aliceToken, "Popular", "Favorited", "Body", nil))
+			fresh := slugOf(createArticle(t, ts, aliceToken, "Fresh", "Just posted", "Body", nil))
+			favoriteArticleHelper(t, ts, bobToken, popular)
+
+			want := map[string][]string{
+				data.ArticleSortRecent:   {fresh, popular, old},
+				data.ArticleSortOldest:   {old, popular, fresh},
+				data.ArticleSortTrending: {popular, fresh, old},
+			}
+
+			list := func(t *testing.T, path string) []string {
+				t.Helper()
+
+				var response struct {
+					Articles      []data.Article     `json:"articles"`
+					ArticlesCount int                `json:"articlesCount"`
+					Pagination    paginationMetadata `json:"pagination"`
+				}
+				require.NoError(t, json.Unmarshal(getRawBody(t, ts, path, map[string]string{"Authorization": "Token " + bobToken}), &response))
+
+				slugs := make([]string, len(response.Articles))
+				for i, article := range response.Articles {
+					slugs[i] = article.Slug
+				}
+				return slugs
+			}
+
+			assert.Equal(t, want[tc.defaultSort.list], list(t, "/articles"))
+			assert.Equal(t, want[tc.defaultSort.feed], list(t, "/articles/feed"))
+
+			// An explicit sort parameter overrides the configured default
+			for _, sort := range data.ArticleSorts {
+				assert.Equal(t, want[sort], list(t, "/articles?sort="+sort))
+				assert.Equal(t, want[sort], list(t, "/articles/feed?sort="+sort))
+			}
+
+			for _, path := range []string{"/articles?sort=random", "/articles/feed?sort=random"} {
+				res, err := ts.executeRequest(http.MethodGet, path, "", map[string]string{"Authorization": "Token " + bobToken})
+				require.NoError(t, err)
+				defer res.Body.Close() //nolint: errcheck
+				assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+				var response errorResponse
+				readJsonResponse(t, res.Body, &response)
+				assert.Equal(t, []string{"Sort must be one of recent, oldest, trending"}, response.Errors)
+			}
+		})
+	}
+}
+
+func TestRelatedArticlesHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		registerUser(t, ts, name, name+"@example.com", "password123")
+	}
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	carolToken := loginUser(t, ts, "carol@example.com", "password123")
+
+	slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+	source := createArticle(t, ts, aliceToken, "Source", "The article", "Body", []string{"go", "postgres", "docker"})
+	allShared := slugOf(createArticle(t, ts, bobToken, "All Shared", "Three tags", "Body", []string{"docker", "go", "postgres"}))
+	oneShared := slugOf(createArticle(t, ts, bobToken, "One Shared", "One tag", "Body", []string{"go", "rust"}))
+	twoShared := slugOf(createArticle(t, ts, aliceToken, "Two Shared", "Two tags, same author", "Body", []string{"go", "postgres"}))
+	createArticle(t, ts, bobToken, "None Shared", "No tags in common", "Body", []string{"rust"})
+
+	favoriteArticleHelper(t, ts, carolToken, oneShared)
+	followUser(t, ts, carolToken, "bob")
+
+	related := func(t *testing.T, query string, headers map[string]string) []data.Article {
+		t.Helper()
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, source+"/related"+query, headers), &response))
+		assert.Equal(t, len(response.Articles), response.ArticlesCount)
+		return response.Articles
+	}
+
+	slugs := func(articles []data.Article) []string {
+		result := make([]string, len(articles))
+		for i, article := range articles {
+			result[i] = article.Slug
+		}
+		return result
+	}
+
+	t.Run("Ordered by shared tags", func(t *testing.T) {
+		assert.Equal(t, []string{allShared, twoShared, oneShared}, slugs(related(t, "", nil)))
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		assert.Equal(t, []string{allShared}, slugs(related(t, "?limit=1", nil)))
+	})
+
+	t.Run("Exclude same author", func(t *testing.T) {
+		assert.Equal(t, []string{allShared, oneShared}, slugs(related(t, "?excludeAuthor=true", nil)))
+	})
+
+	t.Run("Enriched for the viewer", func(t *testing.T) {
+		for _, article := range related(t, "", map[string]string{"Authorization": "Token " + carolToken}) {
+			assert.Equal(t, article.Slug == oneShared, article.Favorited, article.Slug)
+			assert.Equal(t, article.Author.Username == "bob", article.Author.Following, article.Slug)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		testCases := []struct {
+			path       string
+			wantStatus int
+		}{
+			{"/articles/missing-slug/related", http.StatusNotFound},
+			{source + "/related?excludeAuthor=maybe", http.StatusUnprocessableEntity},
+		}
+		for _, tc := range testCases {
+			res, err := ts.executeRequest(http.MethodGet, tc.path, "", nil)
+			require.NoError(t, err)
+			res.Body.Close() //nolint: errcheck
+			assert.Equal(t, tc.wantStatus, res.StatusCode, tc.path)
+		}
+	})
+}
+
+func TestPaginatedHandlers_MaxPageOffset(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxPageOffset = 100
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	headers := map[string]string{"Authorization": "Token " + aliceToken}
+
+	for _, path := range []string{"/articles", "/articles/feed", "/profiles/alice/commented-articles"} {
+		t.Run(path, func(t *testing.T) {
+			res, err := ts.executeRequest(http.MethodGet, path+"?offset=100", "", headers)
+			require.NoError(t, err)
+			res.Body.Close() //nolint: errcheck
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			res, err = ts.executeRequest(http.MethodGet, path+"?offset=101", "", headers)
+			require.NoError(t, err)
+			defer res.Body.Close() //nolint: errcheck
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+			var resp errorResponse
+			readJsonResponse(t, res.Body, &resp)
+			assert.Equal(t, []string{"Offset must not be more than 100, narrow the results with filters instead"}, resp.Errors)
+		})
+	}
+}
+
+func TestCreateArticleHandler_MaxSlugLength(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxSlugLength = 20
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	title := "A very long title " + strings.Repeat("that keeps going ", 20)
+	location := createArticle(t, ts, aliceToken, title, "Long", "Body", nil)
+
+	slug := strings.TrimPrefix(location, "/articles/")
+	assert.Regexp(t, `^a-very-long-title-th-[a-z0-9]{7}$`, slug)
+
+	res, err := ts.executeRequest(http.MethodGet, location, "", nil)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp getArticleResponse
+	readJsonResponse(t, res.Body, &resp)
+	assert.Equal(t, slug, resp.Article.Slug)
+	assert.Equal(t, title, resp.Article.Title)
+}
+
+func TestFavoriteArticleHandler_IfMatch(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	location := createArticle(t, ts, aliceToken, "Versioned", "Description", "Body", nil)
+
+	// Bob views the article, then Alice edits it
+	res, err := ts.executeRequest(http.MethodGet, location, "", nil)
+	require.NoError(t, err)
+	res.Body.Close() //nolint: errcheck
+	staleETag := res.Header.Get("ETag")
+	require.Equal(t, `"1"`, staleETag)
+
+	res, err = ts.executeRequest(http.MethodPut, location, `{"article":{"description":"Edited"}}`,
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	favorite := func(method, ifMatch string) (*http.Response, getArticleResponse) {
+		t.Helper()
+
+		res, err := ts.executeRequest(method, location+"/favorite", "",
+			map[string]string{"Authorization": "Token " + bobToken, "If-Match": ifMatch})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var resp getArticleResponse
+		if res.StatusCode == http.StatusOK {
+			readJsonResponse(t, res.Body, &resp)
+		}
+		return res, resp
+	}
+
+	t.Run("Stale version is rejected", func(t *testing.T) {
+		res, _ := favorite(http.MethodPost, staleETag)
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+
+		var resp getArticleResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, map[string]string{"Authorization": "Token " + bobToken}), &resp))
+		assert.False(t, resp.Article.Favorited)
+		assert.Equal(t, 0, resp.Article.FavoritesCount)
+	})
+
+	t.Run("Malformed If-Match is rejected", func(t *testing.T) {
+		res, _ := favorite(http.MethodPost, `"not-a-version"`)
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+	})
+
+	t.Run("Current version favorites", func(t *testing.T) {
+		res, resp := favorite(http.MethodPost, `"2"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `"2"`, res.Header.Get("ETag"))
+		assert.True(t, resp.Article.Favorited)
+		assert.Equal(t, 1, resp.Article.FavoritesCount)
+	})
+
+	t.Run("Unfavorite checks the version too", func(t *testing.T) {
+		res, _ := favorite(http.MethodDelete, staleETag)
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+
+		res, resp := favorite(http.MethodDelete, `"2"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.False(t, resp.Article.Favorited)
+		assert.Equal(t, 0, resp.Article.FavoritesCount)
+	})
+}
+
+func TestListArticlesHandler_FavoritesPrivacy(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	slug := strings.TrimPrefix(createArticle(t, ts, bobToken, "Bob's Article", "Description", "Body", nil), "/articles/")
+	favoriteArticleHelper(t, ts, aliceToken, slug)
+
+	favoritedByAlice := func(t *testing.T, headers map[string]string) []string {
+		t.Helper()
+
+		var response struct {
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles?favorited=alice", headers), &response))
+
+		slugs := make([]string, len(response.Articles))
+		for i, article := range response.Articles {
+			slugs[i] = article.Slug
+		}
+		return slugs
+	}
+
+	aliceHeaders := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeaders := map[string]string{"Authorization": "Token " + bobToken}
+
+	setFavoritesPublic := func(t *testing.T, public bool) {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPut, "/user", fmt.Sprintf(`{"user":{"favoritesPublic":%t}}`, public), aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp userResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.Equal(t, public, resp.User.FavoritesPublic)
+	}
+
+	t.Run("Public by default", func(t *testing.T) {
+		assert.Equal(t, []string{slug}, favoritedByAlice(t, nil))
+		assert.Equal(t, []string{slug}, favoritedByAlice(t, bobHeaders))
+		assert.Equal(t, []string{slug}, favoritedByAlice(t, aliceHeaders))
+	})
+
+	t.Run("Private favorites are only listed for their owner", func(t *testing.T) {
+		setFavoritesPublic(t, false)
+
+		assert.Empty(t, favoritedByAlice(t, nil))
+		assert.Empty(t, favoritedByAlice(t, bobHeaders))
+		assert.Equal(t, []string{slug}, favoritedByAlice(t, aliceHeaders))
+	})
+
+	t.Run("Made public again", func(t *testing.T) {
+		setFavoritesPublic(t, true)
+
+		assert.Equal(t, []string{slug}, favoritedByAlice(t, bobHeaders))
+	})
+}
+
+func TestCreateArticleHandler_BlockedTags(t *testing.T) {
+	t.Parallel()
+
+	blocklistFile := filepath.Join(t.TempDir(), "blocked-tags.txt")
+	require.NoError(t, os.WriteFile(blocklistFile, []byte("# Offensive tags\nbadword\n\n  Spam  \n"), 0o600))
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.blockedTagsFile = blocklistFile
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + loginUser(t, ts, "alice@example.com", "password123")}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Blocked tag",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article":{"title":"Blocked","description":"Desc","body":"Body","tagList":["golang","badword"]}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{`tag "badword" is not allowed`},
+			},
+		},
+		handlerTestcase{
+			name:                   "Blocked tag matched case-insensitively",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article":{"title":"Blocked","description":"Desc","body":"Body","tagList":["SPAM"]}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{`tag "spam" is not allowed`},
+			},
+		},
+		handlerTestcase{
+			name:                   "Allowed tags",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article":{"title":"Allowed","description":"Desc","body":"Body","tagList":["golang","spammy"]}}`,
+			wantResponseStatusCode: http.StatusCreated,
+		},
+	)
+
+	// Rejected articles must not leak their tags into the tag list
+	var tags getTagsResponse
+	require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/tags", nil), &tags))
+	assert.Equal(t, []string{"golang", "spammy"}, tags.Tags)
+}
+
+func TestListArticlesHandler_QueryCount(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	require.NotNil(t, ts.app.queryCounter)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	followUser(t, ts, bobToken, "alice")
+
+	var location string
+	for i := range 17 {
+		location = createArticle(t, ts, aliceToken, fmt.Sprintf("Article %d", i), "Counted", "Body", []string{"go"})
+		if i%2 == 0 {
+			favoriteArticleHelper(t, ts, bobToken, strings.TrimPrefix(location, "/articles/"))
+		}
+	}
+
+	// countQueries runs the request and returns the number of queries it executed
+	countQueries := func(t *testing.T, path string, headers map[string]string) int64 {
+		t.Helper()
+
+		ctx, count := data.WithQueryCount(context.Background())
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		ts.router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return count.Load()
+	}
+
+	t.Run("Anonymous list is a single query", func(t *testing.T) {
+		assert.EqualValues(t, 1, countQueries(t, "/articles", nil))
+	})
+
+	t.Run("Enrichment doesn't add per-row queries", func(t *testing.T) {
+		// The authenticated user is cached after the first request
+		bobHeaders := map[string]string{"Authorization": "Token " + bobToken}
+		countQueries(t, "/articles", bobHeaders)
+
+		assert.EqualValues(t, 1, countQueries(t, "/articles", bobHeaders))
+		assert.EqualValues(t, 1, countQueries(t, "/articles/feed", bobHeaders))
+	})
+
+	t.Run("Batched queries are counted", func(t *testing.T) {
+		// The article and its comments are fetched in a single batch of two queries
+		assert.EqualValues(t, 2, countQueries(t, location+"?includeComments=true", nil))
+	})
+}
+
+func TestGetArticleHandler_SnakeCaseJSON(t *testing.T) {
+	t.Parallel()
+
+	articleKeys := func(t *testing.T, snakeCase bool) []string {
+		t.Helper()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.snakeCaseJSON = snakeCase
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+		location := createArticle(t, ts, token, "Shapes", "Key naming", "Body", []string{"go"})
+
+		var response map[string]map[string]any
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location, nil), &response))
+
+		keys := make([]string, 0, len(response["article"]))
+		for key := range response["article"] {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	t.Run("camelCase by default", func(t *testing.T) {
+		t.Parallel()
+
+		keys := articleKeys(t, false)
+		assert.Subset(t, keys, []string{"slug", "tagList", "createdAt", "updatedAt", "favoritesCount", "bodyType", "author"})
+		assert.NotContains(t, keys, "favorites_count")
+	})
+
+	t.Run("snake_case when enabled", func(t *testing.T) {
+		t.Parallel()
+
+		keys := articleKeys(t, true)
+		assert.Subset(t, keys, []string{"slug", "tag_list", "created_at", "updated_at", "favorites_count", "body_type", "author"})
+		assert.NotContains(t, keys, "favoritesCount")
+	})
+}
+
+func TestCreateArticleHandler_DuplicateTagPolicy(t *testing.T) {
+	t.Parallel()
+
+	requestBody := `{"article": {"title": "Duplicates", "description": "d", "body": "b", "tagList": ["Zig", "go", "zig", "api", "go"]}}`
+
+	t.Run("Reject", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t)
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		testHandler(t, ts, handlerTestcase{
+			name:                   "Duplicate tags rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          map[string]string{"Authorization": "Token " + token},
+			requestBody:            requestBody,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"TagList must not contain duplicate tags"},
+			},
+		})
+	})
+
+	t.Run("Dedupe", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.tagPolicy = data.TagPolicyDedupe
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		testHandler(t, ts, handlerTestcase{
+			name:                   "Duplicate tags dropped in given order",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          map[string]string{"Authorization": "Token " + token},
+			requestBody:            requestBody,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response getArticleResponse
+				readJsonResponse(t, res.Body, &response)
+				assert.Equal(t, []string{"zig", "go", "api"}, response.Article.TagList)
+
+				// The stored order is kept too
+				var fetched getArticleResponse
+				require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/"+response.Article.Slug, nil), &fetched))
+				assert.Equal(t, []string{"zig", "go", "api"}, fetched.Article.TagList)
+			},
+		})
+	})
+}
+
+func TestCreateArticleHandler_Cooldown(t *testing.T) {
+	t.Parallel()
+
+	const cooldown = 500 * time.Millisecond
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.articleCooldown = cooldown
+	})
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	postArticle := func(token, title string) *http.Response {
+		body := fmt.Sprintf(`{"article":{"title":%q,"description":"Description","body":"Body"}}`, title)
+		res, err := ts.executeRequest(http.MethodPost, "/articles", body, map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Equal(t, http.StatusCreated, postArticle(aliceToken, "First").StatusCode)
+
+	// The second article straight after the first is throttled
+	res := postArticle(aliceToken, "Second")
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get("Retry-After"))
+	var errResp errorResponse
+	readJsonResponse(t, res.Body, &errResp)
+	assert.Equal(t, []string{"rate limit exceeded"}, errResp.Errors)
+
+	// The cooldown is per user
+	assert.Equal(t, http.StatusCreated, postArticle(bobToken, "Bob's First").StatusCode)
+
+	// Invalid articles are rejected before the cooldown is checked
+	res = postArticle(bobToken, "")
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	// Once the cooldown has passed, creating succeeds again
+	time.Sleep(cooldown + 100*time.Millisecond)
+	assert.Equal(t, http.StatusCreated, postArticle(aliceToken, "Second").StatusCode)
+}
+
+// failOnceArticleStore fails the first article insert and passes later ones through.
+type failOnceArticleStore struct {
+	data.ArticleStoreInterface
+	failed *atomic.Bool
+}
+
+func (s failOnceArticleStore) InsertAndReturn(ctx context.Context, article *data.Article, currentUser *data.User) (*data.Article, error) {
+	if s.failed.CompareAndSwap(false, true) {
+		return nil, errors.New("connection reset")
+	}
+	return s.ArticleStoreInterface.InsertAndReturn(ctx, article, currentUser)
+}
+
+func TestCreateArticleHandler_CooldownAfterFailure(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.articleCooldown = time.Hour
+	})
+	ts.app.modelStore.Articles = failOnceArticleStore{ArticleStoreInterface: ts.app.modelStore.Articles, failed: &atomic.Bool{}}
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	postArticle := func(title string) int {
+		body := fmt.Sprintf(`{"article":{"title":%q,"description":"Description","body":"Body"}}`, title)
+		res, err := ts.executeRequest(http.MethodPost, "/articles", body, map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		return res.StatusCode
+	}
+
+	// A failed creation doesn't start the cooldown, so retrying straight away succeeds
+	assert.Equal(t, http.StatusInternalServerError, postArticle("First"))
+	assert.Equal(t, http.StatusCreated, postArticle("First"))
+
+	// The successful creation does start it
+	assert.Equal(t, http.StatusTooManyRequests, postArticle("Second"))
+}
+
+func TestCreateArticleHandler_CooldownDisabled(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+	require.Nil(t, ts.app.articleCooldown)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	createArticle(t, ts, aliceToken, "First", "Description", "Body", nil)
+	createArticle(t, ts, aliceToken, "Second", "Description", "Body", nil)
+}
+
+func TestBatchArticlesHandler_MaxBatchSlugs(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxBatchSlugs = 3
+	})
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	slug := strings.TrimPrefix(createArticle(t, ts, aliceToken, "Batched", "Description", "Body", nil), "/articles/")
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "At the cap",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/batch",
+			requestBody:            `{"slugs":["` + slug + `","missing-1","missing-2"]}`,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		handlerTestcase{
+			name:                   "Over the cap",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/batch",
+			requestBody:            `{"slugs":["` + slug + `","missing-1","missing-2","missing-3"]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"slugs must not contain more than 3 entries"},
+			},
+		},
+	)
+}
+
+func TestCreateArticleHandler_DebugNewTags(t *testing.T) {
+	t.Parallel()
+
+	type createArticleResponse struct {
+		Article data.Article `json:"article"`
+		NewTags []string     `json:"newTags"`
+	}
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.debugNewTags = true
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	token := loginUser(t, ts, "alice@example.com", "password123")
+	createArticle(t, ts, token, "Existing Tags", "d", "b", []string{"go", "web"})
+
+	createWithTags := func(title, tags string) handlerTestcase {
+		return handlerTestcase{
+			name:                   title,
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          map[string]string{"Authorization": "Token " + token},
+			requestBody:            `{"article": {"title": "` + title + `", "description": "d", "body": "b", "tagList": ` + tags + `}}`,
+			wantResponseStatusCode: http.StatusCreated,
+		}
+	}
+
+	mixed := createWithTags("Mixed tags", `["web", "Zig", "go", "api"]`)
+	mixed.additionalChecks = func(t *testing.T, res *http.Response) {
+		var response createArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{"api", "zig"}, response.NewTags)
+		assert.Equal(t, []string{"api", "go", "web", "zig"}, response.Article.TagList)
+	}
+
+	existing := createWithTags("Only existing tags", `["GO", "zig"]`)
+	existing.additionalChecks = func(t *testing.T, res *http.Response) {
+		var response createArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{}, response.NewTags)
+	}
+
+	noTags := createWithTags("No tags", `[]`)
+	noTags.additionalChecks = func(t *testing.T, res *http.Response) {
+		var response createArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{}, response.NewTags)
+	}
+
+	testHandler(t, ts, mixed, existing, noTags)
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		ts := newTestServer(t)
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		res, err := ts.executeRequest(http.MethodPost, "/articles",
+			`{"article": {"title": "Quiet", "description": "d", "body": "b", "tagList": ["new"]}}`,
+			map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var response map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.NotContains(t, response, "newTags")
+	})
+}
+
+func TestCreateArticleHandler_DescriptionExcerpt(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.excerptLength = 20
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + loginUser(t, ts, "alice@example.com", "password123")}
+
+	assertDescription := func(want string) func(t *testing.T, res *http.Response) {
+		return func(t *testing.T, res *http.Response) {
+			var response getArticleResponse
+			readJsonResponse(t, res.Body, &response)
+			assert.Equal(t, want, response.Article.Description)
+
+			// The excerpt is stored, not just returned
+			var fetched getArticleResponse
+			require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles/"+response.Article.Slug, nil), &fetched))
+			assert.Equal(t, want, fetched.Article.Description)
+		}
+	}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Provided description is unchanged",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Described", "description": "My summary", "body": "The quick brown fox jumps over the lazy dog"}}`,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks:       assertDescription("My summary"),
+		},
+		handlerTestcase{
+			name:                   "Empty description gets an excerpt",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Undescribed", "description": "", "body": "The quick brown fox jumps over the lazy dog"}}`,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks:       assertDescription("The quick brown fox…"),
+		},
+		handlerTestcase{
+			name:                   "Missing description gets an excerpt",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "Short", "body": "A short body"}}`,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks:       assertDescription("A short body"),
+		},
+	)
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		ts := newTestServer(t)
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		testHandler(t, ts, handlerTestcase{
+			name:                   "Empty description is rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          map[string]string{"Authorization": "Token " + token},
+			requestBody:            `{"article": {"title": "Undescribed", "description": "", "body": "Body"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Description must not be empty or whitespace only"},
+			},
+		})
+	})
+}
+
+func TestGetArticleHandler_IncludeComments(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxComments = 2
+	})
+
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		registerUser(t, ts, name, name+"@example.com", "password123")
+	}
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	carolToken := loginUser(t, ts, "carol@example.com", "password123")
+	daveToken := loginUser(t, ts, "dave@example.com", "password123")
+
+	location := createArticle(t, ts, aliceToken, "With Comments", "Description", "Body", []string{"go"})
+	slug := strings.TrimPrefix(location, "/articles/")
+	createCommentHelper(t, ts, aliceToken, location, "Oldest, beyond the cap")
+	createCommentHelper(t, ts, carolToken, location, "From Carol")
+	createCommentHelper(t, ts, daveToken, location, "From Dave")
+
+	followUser(t, ts, bobToken, "alice")
+	followUser(t, ts, bobToken, "carol")
+	favoriteArticleHelper(t, ts, bobToken, slug)
+
+	type articleWithCommentsResponse struct {
+		Article       data.Article `json:"article"`
+		Comments      []comment    `json:"comments"`
+		CommentsCount int          `json:"commentsCount"`
+	}
+
+	getWithComments := func(t *testing.T, headers map[string]string, query ...string) articleWithCommentsResponse {
+		t.Helper()
+
+		path := location + "?" + strings.Join(append([]string{"includeComments=true"}, query...), "&")
+		res, err := ts.executeRequest(http.MethodGet, path, "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response articleWithCommentsResponse
+		readJsonResponse(t, res.Body, &response)
+		return response
+	}
+
+	t.Run("Authenticated viewer", func(t *testing.T) {
+		response := getWithComments(t, map[string]string{"Authorization": "Token " + bobToken})
+
+		assert.Equal(t, slug, response.Article.Slug)
+		assert.Equal(t, []string{"go"}, response.Article.TagList)
+		assert.Equal(t, 1, response.Article.FavoritesCount)
+		assert.True(t, response.Article.Favorited)
+		assert.True(t, response.Article.Author.Following)
+
+		// Newest first, limited to the configured maximum, with the total to show the cut-off
+		require.Len(t, response.Comments, 2)
+		assert.Equal(t, 3, response.CommentsCount)
+		assert.Equal(t, "From Dave", response.Comments[0].Body)
+		assert.Equal(t, "dave", response.Comments[0].Author.Username)
+		assert.False(t, response.Comments[0].Author.Following)
+		assert.Equal(t, "From Carol", response.Comments[1].Body)
+		assert.Equal(t, "carol", response.Comments[1].Author.Username)
+		assert.True(t, response.Comments[1].Author.Following)
+		assert.Empty(t, response.Comments[0].BodyHTML)
+	})
+
+	t.Run("Rendered to HTML", func(t *testing.T) {
+		response := getWithComments(t, nil, "render=html")
+
+		assert.Equal(t, "<p>Body</p>\n", response.Article.BodyHTML)
+		require.Len(t, response.Comments, 2)
+		assert.Equal(t, "From Dave", response.Comments[0].Body)
+		assert.Equal(t, "<p>From Dave</p>\n", response.Comments[0].BodyHTML)
+		assert.Equal(t, "<p>From Carol</p>\n", response.Comments[1].BodyHTML)
+	})
+
+	t.Run("Anonymous viewer", func(t *testing.T) {
+		response := getWithComments(t, nil)
+
+		assert.False(t, response.Article.Favorited)
+		assert.False(t, response.Article.Author.Following)
+		require.Len(t, response.Comments, 2)
+		for _, comment := range response.Comments {
+			assert.False(t, comment.Author.Following)
+		}
+	})
+
+	t.Run("Article without comments", func(t *testing.T) {
+		otherLocation := createArticle(t, ts, aliceToken, "Quiet", "Description", "Body", nil)
+
+		var response articleWithCommentsResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, otherLocation+"?includeComments=true", nil), &response))
+		assert.Equal(t, "Quiet", response.Article.Title)
+		assert.Equal(t, []comment{}, response.Comments)
+		assert.Equal(t, 0, response.CommentsCount)
+	})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Comments are left out by default",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "?includeComments=false",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response map[string]json.RawMessage
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+				assert.NotContains(t, response, "comments")
+			},
+		},
+		handlerTestcase{
+			name:                   "Invalid includeComments",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "?includeComments=maybe",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"includeComments must be true or false"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Unknown article",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/no-such-article?includeComments=true",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	)
+}
+
+func TestListArticlesHandler_GenericListEnvelope(t *testing.T) {
+	t.Parallel()
+
+	listResponses := func(t *testing.T, generic bool) (articles, comments map[string]json.RawMessage) {
+		t.Helper()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.genericListEnvelope = generic
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+		location := createArticle(t, ts, token, "Envelopes", "Key naming", "Body", []string{"go"})
+		createCommentHelper(t, ts, token, location, "First!")
+
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles", nil), &articles))
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, location+"/comments", nil), &comments))
+		return articles, comments
+	}
+
+	t.Run("RealWorld keys by default", func(t *testing.T) {
+		t.Parallel()
+
+		articles, comments := listResponses(t, false)
+		assert.ElementsMatch(t, []string{"articles", "articlesCount", "pagination"}, slices.Collect(maps.Keys(articles)))
+		assert.ElementsMatch(t, []string{"comments", "commentsCount", "pagination"}, slices.Collect(maps.Keys(comments)))
+	})
+
+	t.Run("data and meta when enabled", func(t *testing.T) {
+		t.Parallel()
+
+		articles, comments := listResponses(t, true)
+		assert.ElementsMatch(t, []string{"data", "meta"}, slices.Collect(maps.Keys(articles)))
+		assert.ElementsMatch(t, []string{"data", "meta"}, slices.Collect(maps.Keys(comments)))
+
+		var articleList []data.Article
+		require.NoError(t, json.Unmarshal(articles["data"], &articleList))
+		require.Len(t, articleList, 1)
+		assert.Equal(t, "Envelopes", articleList[0].Title)
+
+		var meta struct {
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(articles["meta"], &meta))
+		assert.Equal(t, 1, meta.ArticlesCount)
+
+		var commentList []data.Comment
+		require.NoError(t, json.Unmarshal(comments["data"], &commentList))
+		require.Len(t, commentList, 1)
+		assert.Equal(t, "First!", commentList[0].Body)
+		assert.JSONEq(t, `{"commentsCount":1,"pagination":{"limit":20,"offset":0,"hasMore":false}}`, string(comments["meta"]))
+	})
+}
+
+func TestFavoriteArticleByIDHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	db := ts.openDB(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeaders := map[string]string{"Authorization": "Token " + bobToken}
+
+	byIDLocation := createArticle(t, ts, aliceToken, "By ID", "Favorited by ID", "Body", []string{"go"})
+	bySlugLocation := createArticle(t, ts, aliceToken, "By slug", "Favorited by slug", "Body", []string{"go"})
+
+	var articleID int64
+	err := db.QueryRow(context.Background(), "SELECT id FROM articles WHERE slug = $1", strings.TrimPrefix(byIDLocation, "/articles/")).Scan(&articleID)
+	require.NoError(t, err)
+	idPath := fmt.Sprintf("/articles/id/%d/favorite", articleID)
+
+	favorite := func(t *testing.T, method, path string) data.Article {
+		t.Helper()
+		res, err := ts.executeRequest(method, path, "", bobHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		return response.Article
+	}
+
+	t.Run("Favorite matches the slug path", func(t *testing.T) {
+		byID := favorite(t, http.MethodPost, idPath)
+		bySlug := favorite(t, http.MethodPost, bySlugLocation+"/favorite")
+
+		assert.Equal(t, strings.TrimPrefix(byIDLocation, "/articles/"), byID.Slug)
+		assert.Equal(t, bySlug.FavoritesCount, byID.FavoritesCount)
+		assert.Equal(t, 1, byID.FavoritesCount)
+		assert.True(t, byID.Favorited)
+		assert.Equal(t, bySlug.Author, byID.Author)
+
+		// Favoriting again is idempotent
+		assert.Equal(t, 1, favorite(t, http.MethodPost, idPath).FavoritesCount)
+	})
+
+	t.Run("Unfavorite matches the slug path", func(t *testing.T) {
+		byID := favorite(t, http.MethodDelete, idPath)
+		bySlug := favorite(t, http.MethodDelete, bySlugLocation+"/favorite")
+
+		assert.Equal(t, bySlug.FavoritesCount, byID.FavoritesCount)
+		assert.Equal(t, 0, byID.FavoritesCount)
+		assert.False(t, byID.Favorited)
+	})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Non-numeric ID",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/id/abc/favorite",
+			requestHeader:          bobHeaders,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"id must be a positive integer"}},
+		},
+		handlerTestcase{
+			name:                   "Non-positive ID",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         "/articles/id/0/favorite",
+			requestHeader:          bobHeaders,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"id must be a positive integer"}},
+		},
+		handlerTestcase{
+			name:                   "Unknown ID when favoriting",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         fmt.Sprintf("/articles/id/%d/favorite", articleID+1000),
+			requestHeader:          bobHeaders,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           errorResponse{Errors: []string{"the requested resource could not be found"}},
+		},
+		handlerTestcase{
+			name:                   "Unknown ID when unfavoriting",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         fmt.Sprintf("/articles/id/%d/favorite", articleID+1000),
+			requestHeader:          bobHeaders,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           errorResponse{Errors: []string{"the requested resource could not be found"}},
+		},
+		handlerTestcase{
+			name:                   "Requires authentication",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         idPath,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	)
+}
+
+func TestListArticlesHandler_Search(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	_ = createArticle(t, ts, aliceToken, "Goroutines Explained", "Concurrency in Go", "Channels and more", []string{"golang"})
+	_ = createArticle(t, ts, aliceToken, "Worker Pools", "Bounded parallelism with GOROUTINES", "Body", []string{"golang"})
+	_ = createArticle(t, ts, bobToken, "Scheduling", "How Go schedules work", "Each goroutine gets a time slice", []string{"runtime"})
+	_ = createArticle(t, ts, bobToken, "React Hooks", "Learn hooks", "100% of components", []string{"react"})
+
+	search := func(t *testing.T, query string) (titles []string, count int) {
+		t.Helper()
+
+		var response struct {
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles?"+query, nil), &response))
+		for _, article := range response.Articles {
+			titles = append(titles, article.Title)
+		}
+		return titles, response.ArticlesCount
+	}
+
+	t.Run("Matches title, description and body case-insensitively", func(t *testing.T) {
+		titles, count := search(t, "search=goroutine")
+		assert.ElementsMatch(t, []string{"Goroutines Explained", "Worker Pools", "Scheduling"}, titles)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Combines with other filters", func(t *testing.T) {
+		titles, count := search(t, "search=goroutine&author=alice")
+		assert.ElementsMatch(t, []string{"Goroutines Explained", "Worker Pools"}, titles)
+		assert.Equal(t, 2, count)
+
+		titles, _ = search(t, "search=goroutine&tag=runtime")
+		assert.Equal(t, []string{"Scheduling"}, titles)
+	})
+
+	t.Run("Total count reflects the search when paginated", func(t *testing.T) {
+		titles, count := search(t, "search=goroutine&limit=1")
+		assert.Len(t, titles, 1)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Wildcards match literally", func(t *testing.T) {
+		titles, _ := search(t, "search="+url.QueryEscape("100%"))
+		assert.Equal(t, []string{"React Hooks"}, titles)
+
+		titles, count := search(t, "search="+url.QueryEscape("_"))
+		assert.Empty(t, titles)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Blank search is ignored", func(t *testing.T) {
+		_, count := search(t, "search=+++")
+		assert.Equal(t, 4, count)
+	})
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "Search too long",
+		requestMethodType:      http.MethodGet,
+		requestUrlPath:         "/articles?search=" + strings.Repeat("a", data.MaxSearchLength+1),
+		wantResponseStatusCode: http.StatusUnprocessableEntity,
+		wantResponse: errorResponse{
+			Errors: []string{fmt.Sprintf("Search must not be more than %d characters", data.MaxSearchLength)},
+		},
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// blocklist is a set of lowercased entries loaded from a file. A nil blocklist contains
+// nothing.
+type blocklist map[string]struct{}
+
+// loadBlocklist reads a blocklist file containing one entry per line. Blank lines and
+// lines starting with # are ignored.
+func loadBlocklist(path string) (blocklist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint: errcheck
+
+	list := make(blocklist)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		list[strings.ToLower(entry)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// contains reports whether entry is on the blocklist, ignoring case.
+func (b blocklist) contains(entry string) bool {
+	_, found := b[strings.ToLower(entry)]
+	return found
+}
+
+// emailDomainBlocklist is a set of lowercased email domains, such as disposable email
+// providers, that may not be used to register. A nil blocklist blocks nothing.
+type emailDomainBlocklist blocklist
+
+// Blocked reports whether the domain of the email address is on the blocklist. Domains
+// are matched case-insensitively.
+func (b emailDomainBlocklist) Blocked(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	return blocklist(b).contains(email[at+1:])
+}
+
+// tagBlocklist is a set of lowercased tags, such as offensive words, that may not be used
+// on articles. A nil blocklist blocks nothing.
+type tagBlocklist blocklist
+
+// Blocked reports whether the tag is on the blocklist. Tags are matched
+// case-insensitively, like normalized tags.
+func (b tagBlocklist) Blocked(tag string) bool {
+	return blocklist(b).contains(tag)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/manas-solves/realworld-backend/internal/data"
@@ -14,7 +15,8 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 
 	var input struct {
 		Comment struct {
-			Body string `json:"body"`
+			Body     string `json:"body"`
+			BodyType string `json:"bodyType"`
 		} `json:"comment"`
 	}
 
@@ -25,10 +27,10 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get the article ID by slug
-	articleID, err := app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
+			app.articleNotFoundResponse(w, r, slug)
 			return
 		}
 		app.serverErrorResponse(w, r, err)
@@ -37,50 +39,105 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 
 	comment := &data.Comment{
 		Body:      input.Comment.Body,
+		BodyType:  input.Comment.BodyType,
 		ArticleID: articleID,
 		AuthorID:  app.contextGetUser(r).ID,
 	}
+	// Comments are markdown unless the client says otherwise, like articles
+	if comment.BodyType == "" {
+		comment.BodyType = data.BodyTypeMarkdown
+	}
 
-	v := validator.New()
+	timezone, ok := app.readTimezone(r)
 
-	if data.ValidateComment(v, comment); !v.Valid() {
+	v := validator.New()
+	data.ValidateComment(v, comment)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	currentUser := app.contextGetUser(r)
 
+	// Throttle comment flooding on a single article if rate limiting is enabled
+	headers := make(http.Header)
+	if app.commentLimiter != nil {
+		ok, remaining, retryAfter := app.commentLimiter.Allow(currentUser.ID, articleID)
+		if !ok {
+			app.rateLimitExceededResponse(w, r, retryAfter)
+			return
+		}
+		// Warn well-behaved clients before they hit the limit
+		if app.commentLimiter.NearLimit(remaining) {
+			headers.Set("X-RateLimit-Warning", fmt.Sprintf("approaching rate limit: %d remaining", remaining))
+		}
+	}
+
 	// Insert comment and get complete comment with author in a single operation
 	// Uses currentUser from context instead of querying database
-	createdComment, err := app.modelStore.Comments.InsertAndReturn(comment, currentUser)
+	createdComment, commentsCount, err := app.modelStore.Comments.InsertAndReturn(r.Context(), comment, currentUser)
 	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.articleNotFoundResponse(w, r, slug)
+			return
+		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	createdComment.Localize(timezone)
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"comment": createdComment}, nil)
+	// Echo the article's updated comment count so clients don't have to refetch it
+	err = app.writeJSON(w, http.StatusCreated, envelope{"comment": createdComment, "commentsCount": commentsCount}, headers)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// maxCommentsPageSize caps the size of a page of comments when -max-comments is 0.
+const maxCommentsPageSize = 100
+
 func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
+	renderHTML, ok := readRenderHTML(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{"render must be html"})
+		return
+	}
+	timezone, ok := app.readTimezone(r)
+
+	// Pages are capped at the configured maximum number of comments, so that articles with
+	// pathological numbers of comments stay cheap to serve. Pages stay capped even when the
+	// number of comments returned with an article is unlimited.
+	maxLimit := app.config.maxComments
+	if maxLimit == 0 {
+		maxLimit = maxCommentsPageSize
+	}
+	pagination := app.readPagination(r, min(20, maxLimit), maxLimit)
+
+	v := validator.New()
+	pagination.Validate(v, app.config.maxPageOffset)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	// Get the article ID by slug (verifies article exists)
-	articleID, err := app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
+			app.articleNotFoundResponse(w, r, slug)
 			return
 		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	// Get all comments for the article (includes author details via JOIN)
-	comments, err := app.modelStore.Comments.GetByArticleID(articleID)
+	// Get a page of the article's comments, newest first (includes author details via JOIN)
+	comments, totalCount, err := app.modelStore.Comments.GetByArticleID(r.Context(), articleID, pagination.Limit, pagination.Offset)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -89,16 +146,282 @@ func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Reques
 	// Set following status if user is authenticated (single bulk query)
 	currentUser := app.contextGetUser(r)
 	if !currentUser.IsAnonymous() {
-		err = app.modelStore.Comments.SetFollowingStatus(comments, currentUser.ID)
+		err = app.modelStore.Comments.SetFollowingStatus(r.Context(), comments, currentUser.ID)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
 		}
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"comments": comments}, nil)
+	// Optionally render the bodies to sanitized HTML, leaving the raw bodies intact
+	if renderHTML {
+		for i := range comments {
+			comments[i].BodyHTML, err = renderBodyHTML(comments[i].BodyType, comments[i].Body)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+	}
+	for i := range comments {
+		comments[i].Localize(timezone)
+	}
+
+	env := envelope{
+		"comments":      comments,
+		"commentsCount": totalCount,
+		"pagination":    pagination.Metadata(totalCount),
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("comments", env), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (app *application) getCommentHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	id, ok := readIDParam(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{idErrorMessage})
+		return
+	}
+
+	renderHTML, ok := readRenderHTML(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{"render must be html"})
+		return
+	}
+	timezone, ok := app.readTimezone(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{timezoneErrorMessage})
+		return
+	}
+
+	// Get the article ID by slug (verifies article exists)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.articleNotFoundResponse(w, r, slug)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	comment, err := app.modelStore.Comments.GetByID(r.Context(), articleID, id)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Reuse the bulk following lookup so the author matches the comment list response
+	currentUser := app.contextGetUser(r)
+	if !currentUser.IsAnonymous() {
+		comments := []data.Comment{*comment}
+		err = app.modelStore.Comments.SetFollowingStatus(r.Context(), comments, currentUser.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		comment = &comments[0]
+	}
+
+	if renderHTML {
+		comment.BodyHTML, err = renderBodyHTML(comment.BodyType, comment.Body)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	comment.Localize(timezone)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"comment": comment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// updateCommentHandler edits the body of one of the current user's comments. Unlike
+// deletion, editing another user's comment is forbidden rather than not found, like
+// editing articles.
+func (app *application) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	user := app.contextGetUser(r)
+
+	id, ok := readIDParam(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{idErrorMessage})
+		return
+	}
+
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.articleNotFoundResponse(w, r, slug)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	comment, err := app.modelStore.Comments.GetByID(r.Context(), articleID, id)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if comment.AuthorID != user.ID {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Comment struct {
+			Body     *string `json:"body"`
+			BodyType *string `json:"bodyType"`
+		} `json:"comment"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Comment.Body != nil {
+		comment.Body = *input.Comment.Body
+	}
+
+	if input.Comment.BodyType != nil {
+		comment.BodyType = *input.Comment.BodyType
+	}
+
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	data.ValidateComment(v, comment)
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.modelStore.Comments.Update(r.Context(), comment, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Resolve the author's following status like the comment list does
+	comments := []data.Comment{*comment}
+	err = app.modelStore.Comments.SetFollowingStatus(r.Context(), comments, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	comments[0].Localize(timezone)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"comment": comments[0]}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteCommentHandler deletes one of the current user's comments. Like article deletion,
+// comments that belong to other users are reported as not found.
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	id, ok := readIDParam(r)
+	if !ok {
+		app.failedValidationResponse(w, r, []string{idErrorMessage})
+		return
+	}
+
+	articleID, err := app.modelStore.Articles.GetIDBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.articleNotFoundResponse(w, r, slug)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.modelStore.Comments.DeleteByID(r.Context(), articleID, id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxBatchCommentsPerArticle caps how many recent comments the batch endpoint returns for
+// each article.
+const maxBatchCommentsPerArticle = 10
+
+// batchCommentsHandler returns the most recent comments on each of the requested articles,
+// grouped by article slug, for views such as a notifications inbox. Articles that don't
+// exist or have no comments are left out of the response.
+func (app *application) batchCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ArticleSlugs []string `json:"articleSlugs"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	timezone, ok := app.readTimezone(r)
+
+	v := validator.New()
+	v.Check(len(input.ArticleSlugs) > 0, "articleSlugs must be provided")
+	v.Check(len(input.ArticleSlugs) <= app.config.maxBatchSlugs,
+		fmt.Sprintf("articleSlugs must not contain more than %d entries", app.config.maxBatchSlugs))
+	v.Check(ok, timezoneErrorMessage)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	comments, err := app.modelStore.Comments.GetRecentBySlugs(r.Context(), input.ArticleSlugs, maxBatchCommentsPerArticle, app.contextGetUser(r).ID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	for _, articleComments := range comments {
+		for i := range articleComments {
+			articleComments[i].Localize(timezone)
+		}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, app.listEnvelope("comments", envelope{"comments": comments}), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
 }
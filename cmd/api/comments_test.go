@@ -1,24 +1,42 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/manas-solves/realworld-backend/internal/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type commentResponse struct {
-	Comment comment `json:"comment"`
+	Comment       comment `json:"comment"`
+	CommentsCount int     `json:"commentsCount"` // Only set when creating a comment
+}
+
+type commentsResponse struct {
+	Comments      []comment          `json:"comments"`
+	CommentsCount int                `json:"commentsCount"`
+	Pagination    paginationMetadata `json:"pagination"`
 }
 
 type comment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Author    profile   `json:"author"`
+	ID             int64      `json:"id"`
+	Body           string     `json:"body"`
+	BodyType       string     `json:"bodyType"`
+	BodyHTML       string     `json:"bodyHtml,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	CreatedAtLocal *time.Time `json:"createdAtLocal,omitempty"`
+	UpdatedAtLocal *time.Time `json:"updatedAtLocal,omitempty"`
+	Author         profile    `json:"author"`
 }
 
 func TestCreateCommentHandler(t *testing.T) {
@@ -111,6 +129,28 @@ func TestCreateCommentHandler(t *testing.T) {
 			requestBody:            `{"comment": {"body": "test"`,
 			wantResponseStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:                   "Comment creation with a numeric body",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			requestBody:            `{"comment": {"body": 42}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"comment.body\" (expected string, got number)"},
+			},
+		},
+		{
+			name:                   "Comment creation with comment wrapper not an object",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			requestBody:            `{"comment": "test"}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse: errorResponse{
+				Errors: []string{"body contains incorrect JSON type for field \"comment\" (expected object, got string)"},
+			},
+		},
 	}
 
 	testHandler(t, ts, testcases...)
@@ -131,10 +171,9 @@ func TestGetCommentsHandler_EmptyAndNotFound(t *testing.T) {
 			requestMethodType:      http.MethodGet,
 			requestUrlPath:         articleLocation + "/comments",
 			wantResponseStatusCode: http.StatusOK,
-			wantResponse: struct {
-				Comments []comment `json:"comments"`
-			}{
-				Comments: []comment{},
+			wantResponse: commentsResponse{
+				Comments:   []comment{},
+				Pagination: paginationMetadata{Limit: 20},
 			},
 		},
 		{
@@ -192,9 +231,7 @@ func TestGetCommentsHandler_WithoutAuthentication(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 8, "Should have 8 comments")
@@ -278,9 +315,7 @@ func TestGetCommentsHandler_WithAuthentication(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 7)
@@ -361,9 +396,7 @@ func TestGetCommentsHandler_DifferentUserPerspectives(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 8)
@@ -402,3 +435,809 @@ func TestGetCommentsHandler_DifferentUserPerspectives(t *testing.T) {
 	assert.Equal(t, 2, eveComments, "Eve should have 2 comments")
 	assert.Equal(t, 1, aliceComments, "Alice should have 1 comment")
 }
+
+func TestCreateCommentHandler_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	const window = 500 * time.Millisecond
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.commentLimit.max = 2
+		cfg.commentLimit.window = window
+	})
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	articleLocation := createArticle(t, ts, aliceToken, "Rate Limited", "Comments are limited", "Body", nil)
+	otherArticleLocation := createArticle(t, ts, aliceToken, "Other Article", "Separate limit", "Body", nil)
+
+	postComment := func(token, location string) *http.Response {
+		res, err := ts.executeRequest(http.MethodPost, location+"/comments",
+			`{"comment":{"body":"spam"}}`, map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		return res
+	}
+
+	// The first two comments within the window succeed
+	require.Equal(t, http.StatusCreated, postComment(bobToken, articleLocation).StatusCode)
+	require.Equal(t, http.StatusCreated, postComment(bobToken, articleLocation).StatusCode)
+
+	// The third is throttled
+	res := postComment(bobToken, articleLocation)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+	var errResp errorResponse
+	readJsonResponse(t, res.Body, &errResp)
+	assert.Equal(t, []string{"rate limit exceeded"}, errResp.Errors)
+
+	// The limit is per user and per article
+	assert.Equal(t, http.StatusCreated, postComment(aliceToken, articleLocation).StatusCode)
+	assert.Equal(t, http.StatusCreated, postComment(bobToken, otherArticleLocation).StatusCode)
+
+	// Once the window has passed, commenting succeeds again
+	time.Sleep(window + 100*time.Millisecond)
+	assert.Equal(t, http.StatusCreated, postComment(bobToken, articleLocation).StatusCode)
+}
+
+func TestCreateCommentHandler_RateLimitWarning(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.commentLimit.max = 10
+		cfg.commentLimit.window = time.Minute
+	})
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Warned", "Comments are limited", "Body", nil)
+
+	postComment := func() *http.Response {
+		res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments",
+			`{"comment":{"body":"chatty"}}`, map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		return res
+	}
+
+	// Well below the limit there is no warning
+	for i := range 8 {
+		res := postComment()
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Empty(t, res.Header.Get("X-RateLimit-Warning"), "comment %d", i+1)
+	}
+
+	// Within 10% of the limit the client is warned, but the comments still go through
+	res := postComment()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "approaching rate limit: 1 remaining", res.Header.Get("X-RateLimit-Warning"))
+
+	res = postComment()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "approaching rate limit: 0 remaining", res.Header.Get("X-RateLimit-Warning"))
+
+	// Then the limit is enforced
+	assert.Equal(t, http.StatusTooManyRequests, postComment().StatusCode)
+}
+
+func TestGetCommentHandler_FollowingStatus(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+
+	res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Thanks for reading!"}}`,
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var created commentResponse
+	readJsonResponse(t, res.Body, &created)
+	commentPath := articleLocation + "/comments/" + strconv.FormatInt(created.Comment.ID, 10)
+
+	// Bob follows the comment author
+	followUser(t, ts, bobToken, "alice")
+
+	getComment := func(headers map[string]string) commentResponse {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, commentPath, "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp commentResponse
+		readJsonResponse(t, res.Body, &resp)
+		return resp
+	}
+
+	t.Run("Follower sees following=true", func(t *testing.T) {
+		resp := getComment(map[string]string{"Authorization": "Token " + bobToken})
+		assert.Equal(t, created.Comment.ID, resp.Comment.ID)
+		assert.Equal(t, "Thanks for reading!", resp.Comment.Body)
+		assert.Equal(t, "alice", resp.Comment.Author.Username)
+		assert.True(t, resp.Comment.Author.Following)
+	})
+
+	t.Run("Anonymous request sees following=false", func(t *testing.T) {
+		resp := getComment(nil)
+		assert.False(t, resp.Comment.Author.Following)
+	})
+
+	t.Run("Unknown comment returns 404", func(t *testing.T) {
+		for _, path := range []string{articleLocation + "/comments/999999", "/articles/missing/comments/1"} {
+			res, err := ts.executeRequest(http.MethodGet, path, "", nil)
+			require.NoError(t, err)
+			res.Body.Close() //nolint: errcheck
+			assert.Equal(t, http.StatusNotFound, res.StatusCode, path)
+		}
+	})
+
+	t.Run("Malformed comment ID returns 422", func(t *testing.T) {
+		for _, path := range []string{articleLocation + "/comments/abc", articleLocation + "/comments/0"} {
+			res, err := ts.executeRequest(http.MethodGet, path, "", nil)
+			require.NoError(t, err)
+			var resp errorResponse
+			readJsonResponse(t, res.Body, &resp)
+			res.Body.Close() //nolint: errcheck
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, path)
+			assert.Equal(t, []string{"id must be a positive integer"}, resp.Errors, path)
+		}
+	})
+}
+
+// deletingArticleStore deletes an article right after its ID is looked up, simulating a
+// concurrent delete between the lookup and the comment insert.
+type deletingArticleStore struct {
+	data.ArticleStoreInterface
+	authorID int64
+}
+
+func (s deletingArticleStore) GetIDBySlug(ctx context.Context, slug string) (int64, error) {
+	id, err := s.ArticleStoreInterface.GetIDBySlug(ctx, slug)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.ArticleStoreInterface.DeleteBySlug(ctx, slug, s.authorID)
+}
+
+func TestCreateCommentHandler_ArticleDeletedBeforeInsert(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+	slug := strings.TrimPrefix(articleLocation, "/articles/")
+
+	alice, err := ts.app.modelStore.Users.GetByEmail(context.Background(), "alice@example.com")
+	require.NoError(t, err)
+
+	t.Run("Store returns ErrRecordNotFound", func(t *testing.T) {
+		articleID, err := ts.app.modelStore.Articles.GetIDBySlug(context.Background(), slug)
+		require.NoError(t, err)
+
+		otherLocation := createArticle(t, ts, aliceToken, "Other Article", "Other description", "Other body", nil)
+		otherID, err := ts.app.modelStore.Articles.GetIDBySlug(context.Background(), strings.TrimPrefix(otherLocation, "/articles/"))
+		require.NoError(t, err)
+		require.NoError(t, ts.app.modelStore.Articles.DeleteBySlug(context.Background(), strings.TrimPrefix(otherLocation, "/articles/"), alice.ID))
+
+		_, _, err = ts.app.modelStore.Comments.InsertAndReturn(context.Background(), &data.Comment{Body: "Too late", ArticleID: otherID, AuthorID: alice.ID}, alice)
+		require.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		// The original article still accepts comments
+		_, _, err = ts.app.modelStore.Comments.InsertAndReturn(context.Background(), &data.Comment{Body: "Just in time", ArticleID: articleID, AuthorID: alice.ID}, alice)
+		require.NoError(t, err)
+	})
+
+	t.Run("Handler returns 410", func(t *testing.T) {
+		ts.app.modelStore.Articles = deletingArticleStore{ArticleStoreInterface: ts.app.modelStore.Articles, authorID: alice.ID}
+
+		res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Too late"}}`,
+			map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		// The article was deleted, so it is reported as gone rather than unknown
+		assert.Equal(t, http.StatusGone, res.StatusCode)
+	})
+}
+
+func TestBatchCommentsHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		registerUser(t, ts, name, name+"@example.com", "password123")
+	}
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	carolToken := loginUser(t, ts, "carol@example.com", "password123")
+	followUser(t, ts, carolToken, "bob")
+
+	dragons := createArticle(t, ts, aliceToken, "Dragons", "About dragons", "Body", nil)
+	castles := createArticle(t, ts, aliceToken, "Castles", "About castles", "Body", nil)
+	quiet := createArticle(t, ts, aliceToken, "Quiet", "No comments yet", "Body", nil)
+	createCommentHelper(t, ts, bobToken, dragons, "first")
+	createCommentHelper(t, ts, bobToken, dragons, "second")
+	createCommentHelper(t, ts, aliceToken, castles, "welcome")
+
+	slugOf := func(location string) string { return strings.TrimPrefix(location, "/articles/") }
+	carolHeader := map[string]string{"Authorization": "Token " + carolToken}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Grouped by article, newest first",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/comments/batch",
+			requestHeader:          carolHeader,
+			requestBody:            `{"articleSlugs": ["` + slugOf(dragons) + `", "` + slugOf(castles) + `", "` + slugOf(quiet) + `", "missing"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response struct {
+					Comments map[string][]comment `json:"comments"`
+				}
+				readJsonResponse(t, res.Body, &response)
+
+				require.Len(t, response.Comments, 2)
+
+				dragonComments := response.Comments[slugOf(dragons)]
+				require.Len(t, dragonComments, 2)
+				assert.Equal(t, "second", dragonComments[0].Body)
+				assert.Equal(t, "first", dragonComments[1].Body)
+				for _, c := range dragonComments {
+					assert.Equal(t, profile{Username: "bob", Following: true}, c.Author)
+				}
+
+				castleComments := response.Comments[slugOf(castles)]
+				require.Len(t, castleComments, 1)
+				assert.Equal(t, "welcome", castleComments[0].Body)
+				assert.Equal(t, profile{Username: "alice", Following: false}, castleComments[0].Author)
+			},
+		},
+		{
+			name:                   "No slugs",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/comments/batch",
+			requestHeader:          carolHeader,
+			requestBody:            `{"articleSlugs": []}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"articleSlugs must be provided"},
+			},
+		},
+		{
+			name:                   "Too many slugs",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/comments/batch",
+			requestHeader:          carolHeader,
+			requestBody:            `{"articleSlugs": ["` + strings.Repeat(`a", "`, ts.app.config.maxBatchSlugs) + `a"]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{fmt.Sprintf("articleSlugs must not contain more than %d entries", ts.app.config.maxBatchSlugs)},
+			},
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+func TestGetCommentsHandler_Pagination(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxComments = 3
+		cfg.maxPageOffset = 10
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	busy := createArticle(t, ts, aliceToken, "Busy", "Many comments", "Body", nil)
+	capped := createArticle(t, ts, aliceToken, "Capped", "Exactly the cap", "Body", nil)
+
+	for i := 1; i <= 5; i++ {
+		createCommentHelper(t, ts, aliceToken, busy, "comment "+strconv.Itoa(i))
+	}
+	for i := 1; i <= 3; i++ {
+		createCommentHelper(t, ts, aliceToken, capped, "comment "+strconv.Itoa(i))
+	}
+
+	// wantPage checks a page of comments, newest first, and its pagination metadata
+	wantPage := func(bodies []string, total int, pagination paginationMetadata) func(t *testing.T, res *http.Response) {
+		return func(t *testing.T, res *http.Response) {
+			var response commentsResponse
+			readJsonResponse(t, res.Body, &response)
+
+			gotBodies := make([]string, len(response.Comments))
+			for i, c := range response.Comments {
+				gotBodies[i] = c.Body
+			}
+			assert.Equal(t, bodies, gotBodies)
+			assert.Equal(t, total, response.CommentsCount)
+			assert.Equal(t, pagination, response.Pagination)
+		}
+	}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "First page by default, capped at the maximum",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         busy + "/comments",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: wantPage([]string{"comment 5", "comment 4", "comment 3"}, 5,
+				paginationMetadata{Limit: 3, Offset: 0, HasMore: true}),
+		},
+		handlerTestcase{
+			name:                   "Limit above the maximum is capped",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         busy + "/comments?limit=50",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: wantPage([]string{"comment 5", "comment 4", "comment 3"}, 5,
+				paginationMetadata{Limit: 3, Offset: 0, HasMore: true}),
+		},
+		handlerTestcase{
+			name:                   "Later page",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         busy + "/comments?limit=2&offset=2",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: wantPage([]string{"comment 3", "comment 2"}, 5,
+				paginationMetadata{Limit: 2, Offset: 2, HasMore: true}),
+		},
+		handlerTestcase{
+			name:                   "Last page",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         busy + "/comments?offset=3",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: wantPage([]string{"comment 2", "comment 1"}, 5,
+				paginationMetadata{Limit: 3, Offset: 3, HasMore: false}),
+		},
+		handlerTestcase{
+			name:                   "Exactly the cap",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         capped + "/comments",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: wantPage([]string{"comment 3", "comment 2", "comment 1"}, 3,
+				paginationMetadata{Limit: 3, Offset: 0, HasMore: false}),
+		},
+		handlerTestcase{
+			name:                   "Offset above the maximum",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         busy + "/comments?offset=11",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Offset must not be more than 10, narrow the results with filters instead"},
+			},
+		},
+	)
+}
+
+func TestGetCommentsHandler_UnlimitedMaxComments(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxComments = 0
+		cfg.maxPageOffset = 0
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	location := createArticle(t, ts, aliceToken, "Unlimited", "No comment cap", "Body", nil)
+	createCommentHelper(t, ts, aliceToken, location, "Only comment")
+
+	wantPagination := func(pagination paginationMetadata) func(t *testing.T, res *http.Response) {
+		return func(t *testing.T, res *http.Response) {
+			var response commentsResponse
+			readJsonResponse(t, res.Body, &response)
+			assert.Equal(t, pagination, response.Pagination)
+		}
+	}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Pages are still capped",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "/comments?limit=100000",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks:       wantPagination(paginationMetadata{Limit: maxCommentsPageSize, Offset: 0, HasMore: false}),
+		},
+		handlerTestcase{
+			name:                   "A huge offset has no more results",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "/comments?offset=" + strconv.Itoa(math.MaxInt),
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks:       wantPagination(paginationMetadata{Limit: 20, Offset: math.MaxInt, HasMore: false}),
+		},
+	)
+}
+
+func TestCreateCommentHandler_CommentsCount(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	articleLocation := createArticle(t, ts, aliceToken, "Counted", "Comments are counted", "Body", nil)
+	otherLocation := createArticle(t, ts, aliceToken, "Other", "Comments elsewhere", "Body", nil)
+	createCommentHelper(t, ts, bobToken, otherLocation, "Not counted")
+
+	postComment := func(token, body string) commentResponse {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "`+body+`"}}`,
+			map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var resp commentResponse
+		readJsonResponse(t, res.Body, &resp)
+		return resp
+	}
+
+	assert.Equal(t, 1, postComment(bobToken, "First").CommentsCount)
+	assert.Equal(t, 2, postComment(aliceToken, "Second").CommentsCount)
+
+	resp := postComment(bobToken, "Third")
+	assert.Equal(t, 3, resp.CommentsCount)
+	assert.Equal(t, "Third", resp.Comment.Body)
+}
+
+func TestGetCommentsHandler_RenderHTML(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	articleLocation := createArticle(t, ts, aliceToken, "Rendered", "Comments are rendered", "Body", nil)
+
+	postComment := func(t *testing.T, requestBody string) comment {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", requestBody, aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var resp commentResponse
+		readJsonResponse(t, res.Body, &resp)
+		return resp.Comment
+	}
+
+	markdownComment := postComment(t, `{"comment": {"body": "Some *emphasis*"}}`)
+	assert.Equal(t, data.BodyTypeMarkdown, markdownComment.BodyType)
+	assert.Empty(t, markdownComment.BodyHTML)
+	maliciousComment := postComment(t, `{"comment": {"body": "Hi <script>alert('xss')</script> [click](javascript:alert(1))"}}`)
+	plainComment := postComment(t, `{"comment": {"body": "Plain *text* <b>", "bodyType": "plain"}}`)
+	assert.Equal(t, data.BodyTypePlain, plainComment.BodyType)
+
+	getComments := func(t *testing.T, query string) map[int64]comment {
+		t.Helper()
+
+		var resp struct {
+			Comments []comment `json:"comments"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, articleLocation+"/comments"+query, nil), &resp))
+		byID := make(map[int64]comment, len(resp.Comments))
+		for _, c := range resp.Comments {
+			byID[c.ID] = c
+		}
+		return byID
+	}
+
+	t.Run("Not rendered by default", func(t *testing.T) {
+		for _, c := range getComments(t, "") {
+			assert.Empty(t, c.BodyHTML)
+		}
+	})
+
+	t.Run("Rendered with render=html", func(t *testing.T) {
+		comments := getComments(t, "?render=html")
+		require.Len(t, comments, 3)
+
+		assert.Equal(t, "Some *emphasis*", comments[markdownComment.ID].Body)
+		assert.Equal(t, "<p>Some <em>emphasis</em></p>\n", comments[markdownComment.ID].BodyHTML)
+		assert.Equal(t, "<p>Plain *text* &lt;b&gt;</p>", comments[plainComment.ID].BodyHTML)
+
+		malicious := comments[maliciousComment.ID].BodyHTML
+		assert.NotContains(t, malicious, "<script")
+		assert.NotContains(t, malicious, "javascript:")
+		assert.Contains(t, comments[maliciousComment.ID].Body, "<script>")
+	})
+
+	t.Run("Single comment rendered", func(t *testing.T) {
+		var resp commentResponse
+		body := getRawBody(t, ts, fmt.Sprintf("%s/comments/%d?render=html", articleLocation, markdownComment.ID), nil)
+		require.NoError(t, json.Unmarshal(body, &resp))
+		assert.Equal(t, "<p>Some <em>emphasis</em></p>\n", resp.Comment.BodyHTML)
+	})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Invalid render value",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         articleLocation + "/comments?render=pdf",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"render must be html"}},
+		},
+		handlerTestcase{
+			name:                   "Invalid body type",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          aliceHeader,
+			requestBody:            `{"comment": {"body": "Hi", "bodyType": "html"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"BodyType must be one of markdown, plain"}},
+		},
+	)
+}
+
+func TestDeleteCommentHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Discussed", "Comments get deleted", "Body", nil)
+	otherLocation := createArticle(t, ts, aliceToken, "Other", "Another article", "Body", nil)
+
+	postComment := func(t *testing.T, headers map[string]string, body string) int64 {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "`+body+`"}}`, headers)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var resp commentResponse
+		readJsonResponse(t, res.Body, &resp)
+		return resp.Comment.ID
+	}
+
+	bobComment := postComment(t, bobHeader, "Bob's comment")
+	aliceComment := postComment(t, aliceHeader, "Alice's comment")
+	bobPath := fmt.Sprintf("%s/comments/%d", articleLocation, bobComment)
+	notFound := errorResponse{Errors: []string{"the requested resource could not be found"}}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Requires authentication",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         bobPath,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		handlerTestcase{
+			name:                   "Another user's comment",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         bobPath,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           notFound,
+		},
+		handlerTestcase{
+			name:                   "Comment on a different article",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         fmt.Sprintf("%s/comments/%d", otherLocation, bobComment),
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           notFound,
+		},
+		handlerTestcase{
+			name:                   "Unknown article",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         fmt.Sprintf("/articles/missing-article/comments/%d", bobComment),
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           notFound,
+		},
+		handlerTestcase{
+			name:                   "Invalid ID",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         articleLocation + "/comments/abc",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"id must be a positive integer"}},
+		},
+	)
+
+	t.Run("Own comment is deleted", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, bobPath, "", bobHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		var resp struct {
+			Comments []comment `json:"comments"`
+		}
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, articleLocation+"/comments", nil), &resp))
+		require.Len(t, resp.Comments, 1)
+		assert.Equal(t, aliceComment, resp.Comments[0].ID)
+	})
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "Deleting again is not found",
+		requestMethodType:      http.MethodDelete,
+		requestUrlPath:         bobPath,
+		requestHeader:          bobHeader,
+		wantResponseStatusCode: http.StatusNotFound,
+		wantResponse:           notFound,
+	})
+}
+
+func TestDeleteCommentHandler_WritesAuditLog(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	articleLocation := createArticle(t, ts, aliceToken, "Audited", "Audited comments", "Body", nil)
+
+	res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Short-lived"}}`, aliceHeader)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var created commentResponse
+	readJsonResponse(t, res.Body, &created)
+
+	res, err = ts.executeRequest(http.MethodDelete, fmt.Sprintf("%s/comments/%d", articleLocation, created.Comment.ID), "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	db := ts.openDB(t)
+	var aliceID int64
+	err = db.QueryRow(context.Background(), "SELECT id FROM users WHERE username = 'alice'").Scan(&aliceID)
+	require.NoError(t, err)
+
+	var actorID, targetID int64
+	var targetType, targetSlug string
+	err = db.QueryRow(context.Background(), `
+		SELECT actor_id, target_type, target_id, target_slug
+		FROM audit_log
+		WHERE action = 'delete'`).Scan(&actorID, &targetType, &targetID, &targetSlug)
+	require.NoError(t, err)
+
+	assert.Equal(t, aliceID, actorID)
+	assert.Equal(t, "comment", targetType)
+	assert.Equal(t, created.Comment.ID, targetID)
+	assert.Empty(t, targetSlug)
+}
+
+func TestUpdateCommentHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Edited", "Comments get edited", "Body", nil)
+	otherLocation := createArticle(t, ts, aliceToken, "Other", "Another article", "Body", nil)
+
+	res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Teh typo"}}`, bobHeader)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var created commentResponse
+	readJsonResponse(t, res.Body, &created)
+
+	path := fmt.Sprintf("%s/comments/%d", articleLocation, created.Comment.ID)
+	notFound := errorResponse{Errors: []string{"the requested resource could not be found"}}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Requires authentication",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         path,
+			requestBody:            `{"comment": {"body": "Fixed"}}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		handlerTestcase{
+			name:                   "Another user's comment is forbidden",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         path,
+			requestHeader:          aliceHeader,
+			requestBody:            `{"comment": {"body": "Hijacked"}}`,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse: errorResponse{
+				Errors: []string{"your user account doesn't have the necessary permissions to access/modify this resource"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Comment on a different article",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         fmt.Sprintf("%s/comments/%d", otherLocation, created.Comment.ID),
+			requestHeader:          bobHeader,
+			requestBody:            `{"comment": {"body": "Fixed"}}`,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           notFound,
+		},
+		handlerTestcase{
+			name:                   "Unknown comment",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         fmt.Sprintf("%s/comments/%d", articleLocation, created.Comment.ID+1000),
+			requestHeader:          bobHeader,
+			requestBody:            `{"comment": {"body": "Fixed"}}`,
+			wantResponseStatusCode: http.StatusNotFound,
+			wantResponse:           notFound,
+		},
+		handlerTestcase{
+			name:                   "Malformed comment ID",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         articleLocation + "/comments/abc",
+			requestHeader:          bobHeader,
+			requestBody:            `{"comment": {"body": "Fixed"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"id must be a positive integer"}},
+		},
+		handlerTestcase{
+			name:                   "Blank body",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         path,
+			requestHeader:          bobHeader,
+			requestBody:            `{"comment": {"body": "   "}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Body must not be empty or whitespace only"},
+			},
+		},
+	)
+
+	t.Run("Author edits the comment", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodPut, path, `{"comment": {"body": "The typo, fixed"}}`, bobHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var updated commentResponse
+		readJsonResponse(t, res.Body, &updated)
+		assert.Equal(t, created.Comment.ID, updated.Comment.ID)
+		assert.Equal(t, "The typo, fixed", updated.Comment.Body)
+		assert.Equal(t, data.BodyTypeMarkdown, updated.Comment.BodyType)
+		assert.Equal(t, "bob", updated.Comment.Author.Username)
+		assert.False(t, updated.Comment.Author.Following)
+		assert.True(t, created.Comment.CreatedAt.Equal(updated.Comment.CreatedAt), "createdAt should not change")
+		assert.True(t, updated.Comment.UpdatedAt.After(created.Comment.UpdatedAt), "updatedAt should be bumped")
+
+		// The edit is stored
+		var stored commentResponse
+		require.NoError(t, json.Unmarshal(getRawBody(t, ts, path, nil), &stored))
+		assert.Equal(t, "The typo, fixed", stored.Comment.Body)
+		assert.True(t, updated.Comment.UpdatedAt.Equal(stored.Comment.UpdatedAt))
+	})
+
+	t.Run("Edit is audited", func(t *testing.T) {
+		db := ts.openDB(t)
+		var bobID int64
+		err := db.QueryRow(context.Background(), "SELECT id FROM users WHERE username = 'bob'").Scan(&bobID)
+		require.NoError(t, err)
+
+		var actorID, targetID int64
+		var targetType string
+		err = db.QueryRow(context.Background(), `
+			SELECT actor_id, target_type, target_id
+			FROM audit_log
+			WHERE action = 'update'`).Scan(&actorID, &targetType, &targetID)
+		require.NoError(t, err)
+
+		assert.Equal(t, bobID, actorID)
+		assert.Equal(t, "comment", targetType)
+		assert.Equal(t, created.Comment.ID, targetID)
+	})
+}
@@ -1,8 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/manas-solves/realworld-backend/internal/validator"
 )
 
 func (app *application) logError(r *http.Request, err error) {
@@ -22,17 +31,48 @@ func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, st
 	}
 }
 
+// statusClientClosedRequest is the non-standard status, borrowed from nginx, recorded when
+// the client went away before the response was ready.
+const statusClientClosedRequest = 499
+
 // serverErrorResponse will be used when our application encounters an
 // unexpected problem at runtime. It logs the detailed error message, then uses the
 // errorResponse() helper to send a 500 Internal Server Error status code and JSON
-// response (containing a generic error message) to the client.
+// response (containing a generic error message) to the client. Cancellations and
+// timeouts are handed off to clientClosedResponse and timeoutResponse instead.
 func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		app.clientClosedResponse(w)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		app.timeoutResponse(w, r, err)
+		return
+	}
+
 	app.logError(r, err)
 
 	message := "the server encountered a problem and could not process your request"
 	app.errorResponse(w, r, http.StatusInternalServerError, message)
 }
 
+// clientClosedResponse will be used when a request failed because the client disconnected.
+// Nobody is waiting for the response, so the error isn't logged and only the status is set,
+// for the benefit of request logging.
+func (app *application) clientClosedResponse(w http.ResponseWriter) {
+	w.WriteHeader(statusClientClosedRequest)
+}
+
+// timeoutResponse will be used to send a 503 Service Unavailable status code and JSON
+// response to the client when a database operation ran out of time. The error is still
+// logged, since frequent timeouts point to an overloaded database.
+func (app *application) timeoutResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logError(r, err)
+
+	message := "the server timed out processing your request, please try again"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
 // notFoundResponse will be used to send a 404 Not Found status code and
 // JSON response to the client.
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
@@ -40,15 +80,40 @@ func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request)
 	app.errorResponse(w, r, http.StatusNotFound, message)
 }
 
+// goneResponse will be used to send a 410 Gone status code and JSON response to the
+// client for resources that existed but have since been deleted.
+func (app *application) goneResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the requested resource has been deleted"
+	app.errorResponse(w, r, http.StatusGone, message)
+}
+
+// routeMethods are the methods checked when working out which methods a resource supports.
+var routeMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
 // methodNotAllowedResponse will be used to send a 405 Method Not Allowed
-// status code and JSON response to the client.
+// status code and JSON response to the client. The Allow header lists the methods
+// that are routed for the requested path.
 func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	if app.routeIndex != nil {
+		var allowed []string
+		for _, method := range routeMethods {
+			if app.routeIndex.Match(chi.NewRouteContext(), method, r.URL.Path) {
+				allowed = append(allowed, method)
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+
 	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
 	app.errorResponse(w, r, http.StatusMethodNotAllowed, message)
 }
 
 // failedValidationResponse will be used to send a 422 Unprocessable Entity status code and JSON response to the client.
+// The errors are formatted with validator.FormatErrors, sorted when sortValidationErrors is set.
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors []string) {
+	errors = validator.FormatErrors(errors, validator.FormatOptions{Sort: app.config.sortValidationErrors})
 	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors...)
 }
 
@@ -75,3 +140,28 @@ func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Requ
 	message := "your user account doesn't have the necessary permissions to access/modify this resource"
 	app.errorResponse(w, r, http.StatusForbidden, message)
 }
+
+// readOnlyModeResponse will be used to send a 503 Service Unavailable status code and JSON response
+// to the client when a mutating request is made while the server is in read-only mode.
+func (app *application) readOnlyModeResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(readOnlyRetryAfter.Seconds())))
+
+	message := "the server is in read-only mode for maintenance, please try again later"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
+// preconditionFailedResponse will be used to send a 412 Precondition Failed status code and
+// JSON response to the client when an If-Match header doesn't match the resource's version.
+func (app *application) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the resource has been modified since it was fetched, please reload and try again"
+	app.errorResponse(w, r, http.StatusPreconditionFailed, message)
+}
+
+// rateLimitExceededResponse will be used to send a 429 Too Many Requests status code and JSON response
+// to the client. The Retry-After header is set to the number of whole seconds until the client may retry.
+func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	message := "rate limit exceeded"
+	app.errorResponse(w, r, http.StatusTooManyRequests, message)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manas-solves/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTagStore is a tag store whose queries always fail with err.
+type failingTagStore struct {
+	err error
+}
+
+func (s failingTagStore) GetAll(context.Context) ([]string, error) {
+	return nil, s.err
+}
+
+func (s failingTagStore) GetByAuthor(context.Context, int64) ([]data.TagCount, error) {
+	return nil, s.err
+}
+
+func TestServerErrorResponse_ContextErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantLogged bool
+	}{
+		{"Query timed out", fmt.Errorf("query tags: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, true},
+		{"Other error", errors.New("relation \"tags\" does not exist"), http.StatusInternalServerError, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			app := &application{
+				config:     appConfig{env: "testing"},
+				logger:     slog.New(slog.NewTextHandler(&logs, nil)),
+				modelStore: data.ModelStore{Tags: failingTagStore{err: tc.err}},
+			}
+
+			rr := httptest.NewRecorder()
+			app.getTagsHandler(rr, httptest.NewRequest(http.MethodGet, "/tags", nil))
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			assert.Equal(t, tc.wantLogged, logs.Len() > 0, logs.String())
+		})
+	}
+}
+
+// blockingTagStore is a tag store whose GetAll blocks like a slow query until its context
+// is cancelled. It closes started once the query is underway.
+type blockingTagStore struct {
+	failingTagStore
+	started chan struct{}
+}
+
+func (s blockingTagStore) GetAll(ctx context.Context) ([]string, error) {
+	close(s.started)
+	<-ctx.Done()
+	return nil, fmt.Errorf("query tags: %w", ctx.Err())
+}
+
+func TestServerErrorResponse_ClientDisconnects(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	store := blockingTagStore{started: make(chan struct{})}
+	app := &application{
+		config:     appConfig{env: "testing"},
+		logger:     slog.New(slog.NewTextHandler(&logs, nil)),
+		modelStore: data.ModelStore{Tags: store},
+	}
+
+	// Record the response the handler produced, since the client is gone by then
+	responses := make(chan *httptest.ResponseRecorder, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rr := httptest.NewRecorder()
+		app.getTagsHandler(rr, r)
+		responses <- rr
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-store.started
+		cancel()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tags", nil)
+	require.NoError(t, err)
+	_, err = srv.Client().Do(req)
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case rr := <-responses:
+		assert.Equal(t, statusClientClosedRequest, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Empty(t, logs.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not return after the client disconnected")
+	}
+}
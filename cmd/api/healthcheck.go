@@ -5,6 +5,9 @@ import (
 )
 
 // healthcheckHandler is a handler to check the status of the API server.
+// It also reports the applied migration version, so operators can confirm that a deploy
+// ran its migrations. A failed lookup is logged and leaves the migration out rather than
+// failing the health check.
 func (app *application) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
 	env := envelope{
 		"status": "available",
@@ -14,7 +17,14 @@ func (app *application) healthcheckHandler(w http.ResponseWriter, r *http.Reques
 		},
 	}
 
-	err := app.writeJSON(w, http.StatusOK, env, nil)
+	migration, err := app.modelStore.Migrations.Status(r.Context())
+	if err != nil {
+		app.logger.Warn("cannot read migration status", "error", err.Error())
+	} else {
+		env["migration"] = migration
+	}
+
+	err = app.writeJSON(w, http.StatusOK, env, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -3,22 +3,20 @@ package main
 import (
 	"net/http"
 	"testing"
+
+	"github.com/manas-solves/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type healthCheckResponse struct {
-	Status     string            `json:"status"`
-	SystemInfo map[string]string `json:"system_info"`
+	Status     string                `json:"status"`
+	SystemInfo map[string]string     `json:"system_info"`
+	Migration  *data.MigrationStatus `json:"migration"`
 }
 
 func TestHealthcheckHandler(t *testing.T) {
 	t.Parallel()
-	want := healthCheckResponse{
-		Status: "available",
-		SystemInfo: map[string]string{
-			"environment": "development",
-			"version":     version,
-		},
-	}
 
 	requestUrlPath := "/healthcheck"
 
@@ -27,7 +25,18 @@ func TestHealthcheckHandler(t *testing.T) {
 		requestMethodType:      http.MethodGet,
 		requestUrlPath:         requestUrlPath,
 		wantResponseStatusCode: http.StatusOK,
-		wantResponse:           want,
+		additionalChecks: func(t *testing.T, res *http.Response) {
+			var got healthCheckResponse
+			readJsonResponse(t, res.Body, &got)
+
+			assert.Equal(t, "available", got.Status)
+			assert.Equal(t, map[string]string{"environment": "development", "version": version}, got.SystemInfo)
+
+			// The test harness applies every migration before the server starts
+			require.NotNil(t, got.Migration)
+			assert.Positive(t, got.Migration.Version)
+			assert.False(t, got.Migration.Dirty)
+		},
 	}
 
 	methodNotAllowedTC := handlerTestcase{
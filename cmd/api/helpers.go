@@ -6,15 +6,30 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/manas-solves/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
 )
 
 // writeJSON is a helper that writes the provided data to the client in JSON format.
 // The status code will always be included, and the header map is optional (and may be nil).
 // It will also include the "Content-Type: application/json" header in the response.
+// When snake_case responses are enabled, the keys of the response are converted first.
 func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
-	js, err := json.Marshal(data)
+	var payload any = data
+	if app.config.snakeCaseJSON {
+		converted, err := snakeCaseEnvelope(data)
+		if err != nil {
+			return err
+		}
+		payload = converted
+	}
+
+	js, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
@@ -31,6 +46,35 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data envelo
 	return nil
 }
 
+// listEnvelope returns the envelope of a list response whose items are under listKey.
+// When the generic list envelope is enabled, the items are moved to a "data" key and the
+// remaining keys, such as the count and pagination, to a "meta" key, for clients that
+// don't expect the RealWorld keys. Keys of an existing "meta" entry are merged in.
+func (app *application) listEnvelope(listKey string, env envelope) envelope {
+	if !app.config.genericListEnvelope {
+		return env
+	}
+
+	meta := envelope{}
+	for key, value := range env {
+		switch key {
+		case listKey:
+		case "meta":
+			if nested, ok := value.(envelope); ok {
+				for nestedKey, nestedValue := range nested {
+					meta[nestedKey] = nestedValue
+				}
+				continue
+			}
+			meta[key] = value
+		default:
+			meta[key] = value
+		}
+	}
+
+	return envelope{"data": env[listKey], "meta": meta}
+}
+
 // readJSON is a helper that decodes the JSON request body into the provided destination.
 func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	// Use http.MaxBytesReader() to limit the size of the request body to 1MB.
@@ -69,11 +113,12 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any
 
 		// Likewise, catch any *json.UnmarshalTypeError errors. These occur when the
 		// JSON value is the wrong type for the target destination. If the error relates
-		// to a specific field, then we include that in our error message to make it
-		// easier for the client to debug.
+		// to a specific field, then we include that and the expected type in our error
+		// message to make it easier for the client to debug.
 		case errors.As(err, &unmarshalTypeError):
 			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+				return fmt.Errorf("body contains incorrect JSON type for field %q (expected %s, got %s)",
+					unmarshalTypeError.Field, jsonTypeName(unmarshalTypeError.Type), unmarshalTypeError.Value)
 			}
 			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
 
@@ -125,6 +170,55 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any
 	return nil
 }
 
+// etagMatches reports whether an If-None-Match header value matches the given ETag.
+// The header may list several ETags, or be "*" to match any. As required for
+// If-None-Match, weak ETags are compared by their opaque value only.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonTypeName returns the name of the JSON type that decodes into the Go type t, using
+// the same names json.UnmarshalTypeError uses for the received value.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		// encoding/json decodes []byte from a base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.String()
+	}
+}
+
 // readInt reads an integer from a string and returns the default value if
 // the string is empty or not a valid integer.
 func (app *application) readInt(s string, defaultValue int) int {
@@ -140,6 +234,62 @@ func (app *application) readInt(s string, defaultValue int) int {
 	return i
 }
 
+// idErrorMessage is the validation error for a malformed {id} URL parameter.
+const idErrorMessage = "id must be a positive integer"
+
+// readIDParam reads the {id} URL parameter of the article and comment routes. ok is false
+// when it isn't a positive integer.
+func readIDParam(r *http.Request) (id int64, ok bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+	return id, true
+}
+
+// readString reads a string from the query string and returns the default value if
+// the key is not present or empty.
+func (app *application) readString(qs url.Values, key, defaultValue string) string {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	return s
+}
+
+// readCSV reads a comma-separated query string value and returns its non-empty,
+// whitespace-trimmed entries. It returns nil if the key is not present.
+func (app *application) readCSV(qs url.Values, key string) []string {
+	csv := qs.Get(key)
+	if csv == "" {
+		return nil
+	}
+
+	var values []string
+	for _, value := range strings.Split(csv, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// readValues returns every non-empty value of a repeated query string parameter, such as
+// tag in ?tag=go&tag=testing. It returns nil when the parameter is absent.
+func (app *application) readValues(qs url.Values, key string) []string {
+	var values []string
+	for _, value := range qs[key] {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 // Pagination holds pagination parameters with validation.
 // This struct can be used across different endpoints to maintain consistent pagination logic.
 type Pagination struct {
@@ -147,6 +297,32 @@ type Pagination struct {
 	Offset int
 }
 
+// paginationMetadata describes the page of results returned by a paginated list endpoint,
+// so that clients don't have to work it out from the total count themselves.
+type paginationMetadata struct {
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	HasMore bool `json:"hasMore"`
+}
+
+// Metadata returns the pagination metadata for a page read with these parameters out of
+// totalCount results.
+func (p Pagination) Metadata(totalCount int) paginationMetadata {
+	return paginationMetadata{
+		Limit:   p.Limit,
+		Offset:  p.Offset,
+		HasMore: p.Offset < totalCount-p.Limit, // Rather than Offset+Limit, which a huge offset overflows
+	}
+}
+
+// Validate checks that the offset doesn't exceed maxOffset, since Postgres has to scan
+// and discard every skipped row. A maxOffset of 0 means no limit.
+func (p Pagination) Validate(v *validator.Validator, maxOffset int) {
+	if maxOffset > 0 {
+		v.Check(p.Offset <= maxOffset, fmt.Sprintf("Offset must not be more than %d, narrow the results with filters instead", maxOffset))
+	}
+}
+
 // readPagination reads pagination parameters from the HTTP request query string and returns
 // a Pagination struct with validated values. It applies sensible defaults and caps to prevent abuse.
 //
@@ -188,3 +364,20 @@ func (app *application) readPagination(r *http.Request, defaultLimit, maxLimit i
 		Offset: offset,
 	}
 }
+
+// background runs fn in a goroutine tracked by app.wg, so that graceful shutdown waits for
+// it to finish. A panic in fn is logged instead of crashing the server.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("%v", err))
+			}
+		}()
+
+		fn()
+	}()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// validateBaseURL checks that the configured public base URL is an absolute http or https
+// URL without a query or fragment. An empty base URL is valid and keeps links relative.
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("base url must be an absolute http or https URL")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("base url must not have a query or fragment")
+	}
+
+	return nil
+}
+
+// absoluteURL joins a path, which may carry a query, onto the base URL, keeping any path
+// prefix of the base URL. It returns the path as is when no base URL is configured.
+func absoluteURL(baseURL, path string) string {
+	if baseURL == "" {
+		return path
+	}
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// articleURL returns the link to the article with the given slug, absolute when a base URL
+// is configured.
+func (app *application) articleURL(slug string) string {
+	return absoluteURL(app.config.baseURL, "/articles/"+url.PathEscape(slug))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBaseURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		baseURL string
+		valid   bool
+	}{
+		{"", true},
+		{"https://api.example.com", true},
+		{"http://localhost:4000/", true},
+		{"https://example.com/api", true},
+		{"api.example.com", false},
+		{"/api", false},
+		{"ftp://example.com", false},
+		{"https://", false},
+		{"https://example.com?x=1", false},
+		{"https://example.com#top", false},
+		{"https://exa mple.com", false},
+	}
+
+	for _, tc := range testCases {
+		err := validateBaseURL(tc.baseURL)
+		if tc.valid {
+			assert.NoError(t, err, tc.baseURL)
+		} else {
+			assert.Error(t, err, tc.baseURL)
+		}
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		baseURL string
+		path    string
+		want    string
+	}{
+		{"Slug link without base URL", "", "/articles/hello-world-abc1234", "/articles/hello-world-abc1234"},
+		{"Slug link", "https://api.example.com", "/articles/hello-world-abc1234", "https://api.example.com/articles/hello-world-abc1234"},
+		{"Trailing slash", "https://api.example.com/", "/articles/hello-world-abc1234", "https://api.example.com/articles/hello-world-abc1234"},
+		{"Path prefix", "https://example.com/api", "/articles/hello-world-abc1234", "https://example.com/api/articles/hello-world-abc1234"},
+		{"Feed link with query", "https://api.example.com", "/articles/feed?limit=20", "https://api.example.com/articles/feed?limit=20"},
+		{"Feed link without base URL", "", "/articles/feed?limit=20", "/articles/feed?limit=20"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, absoluteURL(tc.baseURL, tc.path))
+		})
+	}
+
+	app := &application{config: appConfig{baseURL: "https://api.example.com"}}
+	assert.Equal(t, "https://api.example.com/articles/hello-world-abc1234", app.articleURL("hello-world-abc1234"))
+}
@@ -4,10 +4,16 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/manas-solves/realworld-backend/internal/data"
+	"github.com/manas-solves/realworld-backend/internal/sanitize"
+	"github.com/manas-solves/realworld-backend/internal/validator"
 	"github.com/manas-solves/realworld-backend/internal/vcs"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var version = vcs.Version()
@@ -18,8 +24,84 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	cfg := parseConfig()
 
+	// The email validator, default image, https-only image setting and password hashing are
+	// package-level state in the data package, so set them once here before any requests are served.
+	emailValidator, err := validator.NewEmailValidator(cfg.emailValidation)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	data.SetEmailValidator(emailValidator)
+	data.SetDefaultImage(cfg.defaultImage)
+	data.SetRequireHTTPSImages(cfg.httpsOnlyURLs)
+	if cfg.httpsOnlyURLs && cfg.defaultImage != "" && !validator.HTTPSURL(cfg.defaultImage) {
+		logger.Error(fmt.Sprintf("default avatar url %q must be https when -https-only-urls is set", cfg.defaultImage))
+		os.Exit(1)
+	}
+
+	if !validator.PermittedValue(cfg.passwordHash.algorithm, data.PasswordHashAlgorithms...) {
+		logger.Error(fmt.Sprintf("invalid password hash algorithm %q, must be one of bcrypt, argon2id", cfg.passwordHash.algorithm))
+		os.Exit(1)
+	}
+	if cfg.passwordHash.bcryptCost < bcrypt.MinCost || cfg.passwordHash.bcryptCost > bcrypt.MaxCost {
+		logger.Error(fmt.Sprintf("invalid bcrypt cost %d, must be between %d and %d", cfg.passwordHash.bcryptCost, bcrypt.MinCost, bcrypt.MaxCost))
+		os.Exit(1)
+	}
+	if cfg.passwordHash.argon2Memory > math.MaxUint32 || cfg.passwordHash.argon2Iterations == 0 || cfg.passwordHash.argon2Iterations > math.MaxUint32 ||
+		cfg.passwordHash.argon2Parallelism == 0 || cfg.passwordHash.argon2Parallelism > math.MaxUint8 {
+		logger.Error("invalid argon2 parameters, iterations and parallelism must be at least 1")
+		os.Exit(1)
+	}
+	data.SetPasswordHashing(cfg.passwordHash.hashing())
+
+	// The rendered HTML allowlist is package-level state in the sanitize package
+	if err := sanitize.SetAllowedTags(strings.Split(cfg.allowedHTMLTags, ",")); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if err := validateBaseURL(cfg.baseURL); err != nil {
+		logger.Error(fmt.Sprintf("invalid base url %q: %v", cfg.baseURL, err))
+		os.Exit(1)
+	}
+
+	for _, sort := range []string{cfg.defaultSort.list, cfg.defaultSort.feed} {
+		if !validator.PermittedValue(sort, data.ArticleSorts...) {
+			logger.Error(fmt.Sprintf("invalid default sort %q, must be one of %s", sort, strings.Join(data.ArticleSorts, ", ")))
+			os.Exit(1)
+		}
+	}
+	if !validator.PermittedValue(cfg.tagPolicy, data.TagPolicies...) {
+		logger.Error(fmt.Sprintf("invalid duplicate tag policy %q, must be one of reject, dedupe", cfg.tagPolicy))
+		os.Exit(1)
+	}
+	if cfg.maxComments < 0 {
+		logger.Error(fmt.Sprintf("invalid max comments %d, must not be negative", cfg.maxComments))
+		os.Exit(1)
+	}
+	if cfg.maxFollowsPageSize <= 0 {
+		logger.Error(fmt.Sprintf("invalid max follows page size %d, must be greater than 0", cfg.maxFollowsPageSize))
+		os.Exit(1)
+	}
+	if cfg.maxBatchSlugs <= 0 {
+		logger.Error(fmt.Sprintf("invalid max batch slugs %d, must be greater than 0", cfg.maxBatchSlugs))
+		os.Exit(1)
+	}
+	if cfg.maxResponseTags < 0 {
+		logger.Error(fmt.Sprintf("invalid max response tags %d, must not be negative", cfg.maxResponseTags))
+		os.Exit(1)
+	}
+	if cfg.excerptLength < 0 {
+		logger.Error(fmt.Sprintf("invalid description excerpt length %d, must not be negative", cfg.excerptLength))
+		os.Exit(1)
+	}
+	if cfg.commentLimit.window <= 0 {
+		logger.Error(fmt.Sprintf("invalid comment limit window %s, must be greater than 0", cfg.commentLimit.window))
+		os.Exit(1)
+	}
+
 	app := newApplication(cfg, logger)
-	err := app.serve()
+	err = app.serve()
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
@@ -30,17 +112,68 @@ func parseConfig() appConfig {
 	var cfg appConfig
 
 	flag.IntVar(&cfg.port, "port", 4000, "API server port")
+	flag.StringVar(&cfg.baseURL, "base-url", "", "Public base URL used to build absolute links, such as https://api.example.com (empty = relative links)")
 	flag.StringVar(&cfg.env, "env", "development", "Environment (development|staging|production)")
+	flag.BoolVar(&cfg.readOnly, "read-only", false, "Reject all mutating requests except login (for maintenance windows)")
+
+	flag.StringVar(&cfg.tls.certFile, "tls-cert", "", "TLS certificate file (enables HTTPS together with -tls-key)")
+	flag.StringVar(&cfg.tls.keyFile, "tls-key", "", "TLS private key file (enables HTTPS together with -tls-cert)")
 
 	flag.StringVar(&cfg.db.dsn, "db-dsn", os.Getenv("DB_DSN"), "PostgreSQL DSN")
 	flag.IntVar(&cfg.db.maxOpenConns, "db-max-open-conns", 50, "PostgreSQL max open connections")
+	flag.IntVar(&cfg.db.minConns, "db-min-conns", 0, "PostgreSQL min open connections kept warm")
+	flag.DurationVar(&cfg.db.healthCheckPeriod, "db-health-check-period", time.Minute, "PostgreSQL interval between health checks of idle connections")
 	flag.DurationVar(&cfg.db.maxIdleTime, "db-max-idle-time", 15*time.Minute, "PostgreSQL max connection idle time")
 	flag.DurationVar(&cfg.db.timeout, "db-timeout", 10*time.Second, "PostgreSQL operation timeout")
+	flag.IntVar(&cfg.db.retryAttempts, "db-retry-attempts", 3, "Attempts for read queries that fail with transient connection errors (1 = no retries)")
+	flag.DurationVar(&cfg.db.retryBackoff, "db-retry-backoff", 50*time.Millisecond, "Initial backoff between read retries (doubled after each attempt)")
 
 	flag.StringVar(&cfg.jwtMaker.secretKey, "jwt-secret", os.Getenv("JWT_SECRET"), "JWT secret key (minimum 32 characters)")
 	flag.StringVar(&cfg.jwtMaker.issuer, "jwt-issuer", os.Getenv("JWT_ISSUER"), "JWT issuer")
 	flag.DurationVar(&cfg.jwtMaker.accessDuration, "jwt-access-duration", 24*time.Hour, "JWT access token duration")
 
+	flag.BoolVar(&cfg.authCookie, "auth-cookie", false, "Set an httpOnly auth cookie on login and accept it when no Authorization header is sent")
+
+	flag.BoolVar(&cfg.userCache.enabled, "user-cache-enabled", true, "Cache authenticated users in memory")
+
+	flag.StringVar(&cfg.emailValidation, "email-validation", validator.EmailValidationLoose, "Email validation mode (loose|strict)")
+	flag.StringVar(&cfg.blockedDomainsFile, "blocked-email-domains-file", "", "File listing email domains that may not register, one per line (empty = none)")
+	flag.StringVar(&cfg.blockedTagsFile, "blocked-tags-file", "", "File listing tags that may not be used on articles, one per line (empty = none)")
+	flag.StringVar(&cfg.defaultImage, "default-avatar-url", "", "Image URL returned for users without an image (empty = none)")
+	flag.BoolVar(&cfg.httpsOnlyURLs, "https-only-urls", false, "Reject image URLs that are not https")
+	flag.StringVar(&cfg.passwordHash.algorithm, "password-hash", data.PasswordHashBcrypt, "Algorithm for new password hashes, existing hashes are migrated on login (bcrypt|argon2id)")
+	flag.IntVar(&cfg.passwordHash.bcryptCost, "bcrypt-cost", data.DefaultBcryptCost, "bcrypt cost of new password hashes, existing hashes are upgraded on login")
+	flag.UintVar(&cfg.passwordHash.argon2Memory, "argon2-memory", uint(data.DefaultArgon2Params.Memory), "argon2id memory cost in KiB")
+	flag.UintVar(&cfg.passwordHash.argon2Iterations, "argon2-iterations", uint(data.DefaultArgon2Params.Iterations), "argon2id number of iterations")
+	flag.UintVar(&cfg.passwordHash.argon2Parallelism, "argon2-parallelism", uint(data.DefaultArgon2Params.Parallelism), "argon2id degree of parallelism")
+	flag.IntVar(&cfg.maxFollows, "max-follows", 0, "Maximum number of users a user may follow (0 = unlimited)")
+	flag.IntVar(&cfg.commentLimit.max, "comment-limit-max", 0, "Maximum comments per user per article within the limit window (0 = disabled)")
+	flag.DurationVar(&cfg.commentLimit.window, "comment-limit-window", time.Minute, "Comment rate limit window")
+	flag.DurationVar(&cfg.articleCooldown, "article-cooldown", 0, "Minimum interval between article creations by the same user (0 = disabled)")
+	flag.IntVar(&cfg.maxArticles, "max-articles-per-user", 0, "Maximum number of articles a user may own (0 = unlimited)")
+	flag.BoolVar(&cfg.forbidSelfFavorite, "forbid-self-favorite", false, "Reject users favoriting their own articles")
+	flag.BoolVar(&cfg.computedFavoritesCount, "computed-favorites-count", false, "Count favorites on read instead of updating a counter on each favorite")
+	flag.IntVar(&cfg.maxResponseTags, "max-response-tags", 50, "Maximum number of tags returned per article in responses (0 = unlimited)")
+	flag.IntVar(&cfg.maxSlugLength, "max-slug-length", 200, "Maximum length of the title part of article slugs (0 = unlimited)")
+	flag.StringVar(&cfg.tagPolicy, "duplicate-tags", data.TagPolicyReject, "Handling of duplicate tags in new articles (reject = fail validation and sort tags | dedupe = drop duplicates and keep tag order)")
+	flag.IntVar(&cfg.maxFollowsPageSize, "max-follows-page-size", 100, "Maximum number of profiles returned per followers/following page")
+	flag.IntVar(&cfg.maxBatchSlugs, "max-batch-slugs", 100, "Maximum number of slugs accepted by the batch article and comment endpoints")
+	flag.IntVar(&cfg.maxComments, "max-comments", 200, "Maximum number of comments returned with an article or per page of its comments, most recent first (0 = unlimited with an article, pages of 100)")
+	flag.IntVar(&cfg.maxPageOffset, "max-page-offset", 10000, "Maximum offset accepted by paginated endpoints (0 = unlimited)")
+	flag.StringVar(&cfg.defaultSort.list, "list-default-sort", data.ArticleSortRecent, "Default ordering of the global article list (recent|oldest|trending)")
+	flag.StringVar(&cfg.defaultSort.feed, "feed-default-sort", data.ArticleSortRecent, "Default ordering of the article feed (recent|oldest|trending)")
+	flag.BoolVar(&cfg.snakeCaseJSON, "snake-case-json", false, "Use snake_case instead of camelCase keys in JSON responses")
+	flag.BoolVar(&cfg.genericListEnvelope, "generic-list-envelope", false, "Wrap article and comment lists in data and meta keys instead of the RealWorld keys")
+	flag.BoolVar(&cfg.sortValidationErrors, "sort-validation-errors", false, "Sort validation errors alphabetically in responses")
+	flag.BoolVar(&cfg.strictSelfUnfollow, "strict-self-unfollow", false, "Reject unfollowing yourself with 422, like following yourself, instead of a no-op")
+	flag.IntVar(&cfg.excerptLength, "description-excerpt-length", 0, "Length of the body excerpt used as the description of new articles created without one (0 = description required)")
+	flag.BoolVar(&cfg.debugNewTags, "debug-new-tags", false, "Include the tags first created by an article in the create article response")
+	flag.StringVar(&cfg.allowedHTMLTags, "allowed-html-tags", "", "Comma-separated HTML tags allowed in rendered bodies in addition to the basic formatting tags, such as table,thead,tbody,tr,th,td,img")
+	flag.StringVar(&cfg.contentSecurityPolicy, "content-security-policy", "default-src 'none'; frame-ancestors 'none'", "Content-Security-Policy header sent with every response (empty = omit)")
+	flag.BoolVar(&cfg.userArticlesCount, "user-articles-count", false, "Include the number of authored articles in current user responses")
+	flag.StringVar(&cfg.defaultTimezone, "default-timezone", "", "Time zone, such as America/New_York, of the local timestamps added to article and comment responses when no tz parameter is given (empty = none)")
+	flag.BoolVar(&cfg.feedEmptyHint, "feed-empty-hint", false, "Explain why the article feed is empty in a meta field of the response")
+
 	// Create a new version boolean flag with the default value of false.
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
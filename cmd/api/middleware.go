@@ -3,9 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/manas-solves/realworld-backend/internal/auth"
 	"github.com/manas-solves/realworld-backend/internal/data"
 )
 
@@ -23,41 +28,75 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
+// secureHeaders sets response headers that harden browser clients consuming the API. The
+// Content-Security-Policy header is only set when a policy is configured.
+func (app *application) secureHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if app.config.contentSecurityPolicy != "" {
+			w.Header().Set("Content-Security-Policy", app.config.contentSecurityPolicy)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readOnlyRetryAfter is the Retry-After hint sent for requests rejected in read-only mode.
+const readOnlyRetryAfter = 5 * time.Minute
+
+// readOnlyPostPaths are POST endpoints that don't modify any data and so remain available
+// in read-only mode.
+var readOnlyPostPaths = []string{"/users/login", "/users/logout", "/articles/batch", "/comments/batch", "/profiles/following-status"}
+
+// enforceReadOnly rejects mutating requests with a 503 Service Unavailable response while
+// the server is in read-only mode. Safe methods are always allowed, and so are the POST
+// endpoints in readOnlyPostPaths.
+func (app *application) enforceReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.config.readOnly {
+			switch {
+			case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions:
+			case r.Method == http.MethodPost && slices.Contains(readOnlyPostPaths, r.URL.Path):
+			default:
+				app.readOnlyModeResponse(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // authenticate checks the Authorization header and verifies the JWT.
 // If the JWT is valid, it retrieves the user details based on the user ID and sets the user details in the request context.
 // Unlike before, this middleware now rejects invalid tokens instead of silently treating them as anonymous.
 // Only missing tokens result in anonymous access.
+// When cookie auth is enabled, requests without an Authorization header fall back to the
+// token in the auth cookie.
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		header := r.Header.Get("Authorization")
-
-		// No authorization header - proceed as anonymous user
-		if header == "" {
-			r = app.contextSetUser(r, data.AnonymousUser)
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Authorization header present but malformed - reject explicitly
-		if !strings.HasPrefix(header, "Token ") {
+		tokenString, claims, failure := app.verifyRequestToken(r)
+		if failure != "" {
+			app.logAuthFailure(r, failure)
 			app.invalidAuthenticationTokenResponse(w, r)
 			return
 		}
 
-		tokenString := strings.TrimPrefix(header, "Token ")
-
-		// Verify the token - reject if invalid or expired
-		claims, err := app.jwtMaker.VerifyToken(tokenString)
-		if err != nil {
-			app.invalidAuthenticationTokenResponse(w, r)
+		// No token - proceed as anonymous user
+		if tokenString == "" {
+			r = app.contextSetUser(r, data.AnonymousUser)
+			next.ServeHTTP(w, r)
 			return
 		}
 
 		// GetByID now handles caching automatically
-		user, err := app.modelStore.Users.GetByID(claims.UserID)
+		user, err := app.modelStore.Users.GetByID(r.Context(), claims.UserID)
 		if err != nil {
 			// User not found - token references non-existent user (deleted account)
 			if errors.Is(err, data.ErrRecordNotFound) {
+				app.logAuthFailure(r, authFailureUnknownUser)
 				app.invalidAuthenticationTokenResponse(w, r)
 				return
 			}
@@ -73,6 +112,71 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// verifyRequestToken reads the token from the Authorization header, or from the auth cookie
+// when cookie auth is enabled, and verifies it. It returns an empty token and no failure
+// when the request carries no token, and the reason to log when the token is rejected.
+func (app *application) verifyRequestToken(r *http.Request) (tokenString string, claims *auth.Claims, failure string) {
+	header := r.Header.Get("Authorization")
+
+	if header == "" && app.config.authCookie {
+		if cookie, err := r.Cookie(authCookieName); err == nil && cookie.Value != "" {
+			header = "Token " + cookie.Value
+		}
+	}
+
+	if header == "" {
+		return "", nil, ""
+	}
+
+	// Authorization header present but malformed - reject explicitly
+	if !strings.HasPrefix(header, "Token ") {
+		return "", nil, authFailureMalformedHeader
+	}
+
+	// Tolerate extra whitespace around the token, but reject an empty token outright
+	// rather than handing it to the JWT parser
+	tokenString = strings.TrimSpace(strings.TrimPrefix(header, "Token "))
+	if tokenString == "" {
+		return "", nil, authFailureInvalidToken
+	}
+
+	// Verify the token - reject if invalid or expired
+	claims, err := app.jwtMaker.VerifyToken(tokenString)
+	if err != nil {
+		if errors.Is(err, auth.ErrExpiredToken) {
+			return "", nil, authFailureExpiredToken
+		}
+		return "", nil, authFailureInvalidToken
+	}
+
+	return tokenString, claims, ""
+}
+
+// Reasons logged by logAuthFailure.
+const (
+	authFailureMalformedHeader = "malformed header"
+	authFailureInvalidToken    = "invalid token"
+	authFailureExpiredToken    = "expired token"
+	authFailureUnknownUser     = "unknown user"
+)
+
+// logAuthFailure logs a rejected authentication attempt with the client IP and request ID
+// so that brute-force attempts are visible. The token itself is never logged.
+func (app *application) logAuthFailure(r *http.Request, reason string) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	app.logger.Info("authentication failed",
+		"reason", reason,
+		"ip", ip,
+		"request_id", middleware.GetReqID(r.Context()),
+		"method", r.Method,
+		"url", r.URL.RequestURI(),
+	)
+}
+
 // requireAuthenticatedUser checks if the user is authenticated.
 // If not, it sends a 401 unauthorized response.
 func (app *application) requireAuthenticatedUser(next http.Handler) http.Handler {
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/manas-solves/realworld-backend/internal/auth"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRecoverPanic(t *testing.T) {
@@ -28,3 +35,201 @@ func TestRecoverPanic(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 	assert.Equal(t, res.Header.Get("Connection"), "close")
 }
+
+func TestSecureHeaders(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		csp     string
+		wantCSP string
+	}{
+		{"Configured policy", "default-src 'none'", "default-src 'none'"},
+		{"Empty policy is omitted", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := &application{config: appConfig{contentSecurityPolicy: tc.csp}}
+			handler := app.secureHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tags", nil))
+
+			res := rr.Result()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"))
+			assert.Equal(t, "DENY", res.Header.Get("X-Frame-Options"))
+			assert.Equal(t, "strict-origin-when-cross-origin", res.Header.Get("Referrer-Policy"))
+			assert.Equal(t, tc.wantCSP, res.Header.Get("Content-Security-Policy"))
+			_, hasCSP := res.Header["Content-Security-Policy"]
+			assert.Equal(t, tc.wantCSP != "", hasCSP)
+		})
+	}
+}
+
+func TestSecureHeaders_AppliedGlobally(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.contentSecurityPolicy = "default-src 'none'"
+	})
+
+	res, err := ts.executeRequest(http.MethodGet, "/tags", "", nil)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", res.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", res.Header.Get("Referrer-Policy"))
+	assert.Equal(t, "default-src 'none'", res.Header.Get("Content-Security-Policy"))
+}
+
+func TestAuthenticate_LogsFailures(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	var logs bytes.Buffer
+	ts.app.logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	maker, err := auth.NewJWTMaker(ts.app.config.jwtMaker.secretKey, ts.app.config.jwtMaker.issuer)
+	require.NoError(t, err)
+	expiredToken, err := maker.CreateToken(1, -time.Minute)
+	require.NoError(t, err)
+	unknownUserToken, err := maker.CreateToken(9999, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		header     string
+		wantReason string
+	}{
+		{"Malformed header", "Bearer some-token", authFailureMalformedHeader},
+		{"Invalid token", "Token not-a-jwt", authFailureInvalidToken},
+		{"Expired token", "Token " + expiredToken, authFailureExpiredToken},
+		{"Unknown user", "Token " + unknownUserToken, authFailureUnknownUser},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.Reset()
+
+			res, err := ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Authorization": tc.header})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			assert.Equal(t, "authentication failed", entry["msg"])
+			assert.Equal(t, tc.wantReason, entry["reason"])
+			assert.Contains(t, entry, "ip")
+			assert.NotEmpty(t, entry["request_id"])
+
+			// The token must never be logged
+			token := strings.TrimPrefix(strings.TrimPrefix(tc.header, "Token "), "Bearer ")
+			assert.NotContains(t, logs.String(), token)
+		})
+	}
+}
+
+func TestAuthenticate_TokenWhitespace(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	token := loginUser(t, ts, "alice@example.com", "password123")
+
+	unauthorized := errorResponse{Errors: []string{"invalid or missing authentication token"}}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Empty token after prefix",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user",
+			requestHeader:          map[string]string{"Authorization": "Token "},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		{
+			name:                   "Whitespace-only token",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user",
+			requestHeader:          map[string]string{"Authorization": "Token    "},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		{
+			name:                   "Valid token with surrounding whitespace",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user",
+			requestHeader:          map[string]string{"Authorization": "Token   " + token + "  "},
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var got userResponse
+				readJsonResponse(t, res.Body, &got)
+				assert.Equal(t, "alice", got.User.Username)
+				assert.Equal(t, token, got.User.Token)
+			},
+		},
+	}
+	testHandler(t, ts, testcases...)
+}
+
+func TestEnforceReadOnly(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	// Seed data before switching to read-only mode
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	createArticle(t, ts, aliceToken, "Readable", "Still readable", "Body", nil)
+	ts.app.config.readOnly = true
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "POST is rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"New","description":"New article","body":"Body"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusServiceUnavailable,
+			wantResponse: errorResponse{
+				Errors: []string{"the server is in read-only mode for maintenance, please try again later"},
+			},
+			wantResponseHeader: map[string]string{"Retry-After": "300"},
+		},
+		handlerTestcase{
+			name:                   "PUT is rejected",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/user",
+			requestBody:            `{"user":{"bio":"new bio"}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusServiceUnavailable,
+		},
+		handlerTestcase{
+			name:                   "DELETE is rejected",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         "/profiles/alice/follow",
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusServiceUnavailable,
+		},
+		handlerTestcase{
+			name:                   "Login is allowed",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users/login",
+			requestBody:            `{"user":{"email":"alice@example.com","password":"password123"}}`,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	)
+
+	// Reads keep working
+	var articles struct {
+		Articles      []json.RawMessage  `json:"articles"`
+		ArticlesCount int                `json:"articlesCount"`
+		Pagination    paginationMetadata `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(getRawBody(t, ts, "/articles", nil), &articles))
+	assert.Equal(t, 1, articles.ArticlesCount)
+}
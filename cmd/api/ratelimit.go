@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// commentLimiter limits how many comments a user can post on a single article within a
+// time window. It uses short-lived fixed-window counters keyed by user and article.
+type commentLimiter struct {
+	counters *cache.Cache
+	max      int
+	window   time.Duration
+}
+
+// newCommentLimiter creates a commentLimiter allowing max comments per window. The window
+// must be positive, as the counters would otherwise never expire.
+func newCommentLimiter(max int, window time.Duration) *commentLimiter {
+	return &commentLimiter{
+		counters: cache.New(window, window),
+		max:      max,
+		window:   window,
+	}
+}
+
+// Allow records a comment attempt by the user on the article. If the attempt is within
+// the limit it returns true and the number of attempts remaining in the current window;
+// otherwise it returns false and the time until the current window resets.
+func (l *commentLimiter) Allow(userID, articleID int64) (allowed bool, remaining int, retryAfter time.Duration) {
+	key := fmt.Sprintf("comment:%d:%d", userID, articleID)
+
+	for {
+		// Start a new window if there isn't one already
+		if err := l.counters.Add(key, 1, l.window); err == nil {
+			return true, l.max - 1, 0
+		}
+
+		count, err := l.counters.IncrementInt(key, 1)
+		if err != nil {
+			// The window expired between Add and IncrementInt, so start a new one
+			continue
+		}
+
+		if count <= l.max {
+			return true, l.max - count, 0
+		}
+
+		_, expiration, found := l.counters.GetWithExpiration(key)
+		if !found {
+			continue
+		}
+		return false, 0, time.Until(expiration)
+	}
+}
+
+// NearLimit reports whether remaining attempts are within 10% of the limit (rounded up),
+// at which point clients are warned to slow down before they get blocked.
+func (l *commentLimiter) NearLimit(remaining int) bool {
+	return remaining <= (l.max+9)/10
+}
+
+// articleCooldown enforces a minimum interval between article creations by the same user.
+// It keeps a short-lived entry per user that expires once the cooldown has passed.
+type articleCooldown struct {
+	lastCreated *cache.Cache
+	interval    time.Duration
+}
+
+// newArticleCooldown creates an articleCooldown requiring interval between creations.
+func newArticleCooldown(interval time.Duration) *articleCooldown {
+	return &articleCooldown{
+		lastCreated: cache.New(interval, interval),
+		interval:    interval,
+	}
+}
+
+// Allow records an article creation by the user if the user hasn't created one within the
+// cooldown interval. Otherwise it returns false and the time until the cooldown ends.
+func (c *articleCooldown) Allow(userID int64) (allowed bool, retryAfter time.Duration) {
+	key := fmt.Sprintf("article:%d", userID)
+
+	for {
+		// Add fails if the user is still cooling down from their last creation
+		if err := c.lastCreated.Add(key, struct{}{}, c.interval); err == nil {
+			return true, 0
+		}
+
+		_, expiration, found := c.lastCreated.GetWithExpiration(key)
+		if !found {
+			// The cooldown ended between Add and GetWithExpiration
+			continue
+		}
+		return false, time.Until(expiration)
+	}
+}
+
+// Release clears the user's cooldown, for when the creation recorded by Allow failed and
+// the user should be able to retry straight away.
+func (c *articleCooldown) Release(userID int64) {
+	c.lastCreated.Delete(fmt.Sprintf("article:%d", userID))
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // routes returns a new chi router containing the application routes.
@@ -11,41 +15,92 @@ func (app *application) routes() *chi.Mux {
 	r.NotFound(app.notFoundResponse)
 	r.MethodNotAllowed(app.methodNotAllowedResponse)
 
-	r.Use(app.recoverPanic, app.authenticate)
-
-	r.Get("/healthcheck", app.healthcheckHandler)
+	r.Use(middleware.RequestID, app.recoverPanic, app.secureHeaders, app.enforceReadOnly)
 
 	r.Route("/users", func(r chi.Router) {
-		r.Post("/", app.registerUserHandler)
-		r.Post("/login", app.loginUserHandler)
-	})
+		// Validating a token only checks the token itself, so it never touches the database
+		r.Get("/token/validate", app.validateTokenHandler)
 
-	r.Route("/user", func(r chi.Router) {
-		r.Use(app.requireAuthenticatedUser)
-		r.Get("/", app.getCurrentUserHandler)
-		r.Put("/", app.updateUserHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(app.authenticate)
+			r.Post("/", app.registerUserHandler)
+			r.Post("/login", app.loginUserHandler)
+			r.Post("/logout", app.logoutUserHandler)
+		})
 	})
 
-	r.Route("/profiles/{username}", func(r chi.Router) {
-		r.Get("/", app.getProfileHandler)
-		r.With(app.requireAuthenticatedUser).Post("/follow", app.followUserHandler)
-		r.With(app.requireAuthenticatedUser).Delete("/follow", app.unfollowUserHandler)
-	})
+	r.Group(func(r chi.Router) {
+		r.Use(app.authenticate)
+
+		r.Get("/healthcheck", app.healthcheckHandler)
+
+		r.Route("/user", func(r chi.Router) {
+			r.Use(app.requireAuthenticatedUser)
+			r.Get("/", app.getCurrentUserHandler)
+			r.Put("/", app.updateUserHandler)
+			r.Get("/notifications/count", app.notificationCountsHandler)
+		})
+
+		r.With(app.requireAuthenticatedUser).Post("/profiles/following-status", app.followingStatusHandler)
+		r.With(app.requireAuthenticatedUser).Post("/profiles/follow", app.bulkFollowHandler)
+
+		r.Route("/profiles/{username}", func(r chi.Router) {
+			r.Get("/", app.getProfileHandler)
+			r.Get("/commented-articles", app.commentedArticlesHandler)
+			r.Get("/followers", app.followersHandler)
+			r.Get("/following", app.followingHandler)
+			r.Get("/tags", app.authorTagsHandler)
+			r.With(app.requireAuthenticatedUser).Post("/follow", app.followUserHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/follow", app.unfollowUserHandler)
+		})
 
-	r.Route("/articles", func(r chi.Router) {
-		r.Get("/", app.listArticlesHandler)
-		r.With(app.requireAuthenticatedUser).Get("/feed", app.feedArticlesHandler)
-		r.With(app.requireAuthenticatedUser).Post("/", app.createArticleHandler)
-		r.Get("/{slug}", app.getArticleHandler)
-		r.With(app.requireAuthenticatedUser).Put("/{slug}", app.updateArticleHandler)
-		r.With(app.requireAuthenticatedUser).Delete("/{slug}", app.deleteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Post("/{slug}/favorite", app.favoriteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Delete("/{slug}/favorite", app.unfavoriteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Post("/{slug}/comments", app.createCommentHandler)
-		r.Get("/{slug}/comments", app.getCommentsHandler)
+		r.Route("/articles", func(r chi.Router) {
+			r.Get("/", app.listArticlesHandler)
+			r.With(app.requireAuthenticatedUser).Get("/feed", app.feedArticlesHandler)
+			r.Get("/trending", app.trendingArticlesHandler)
+			r.Post("/batch", app.batchArticlesHandler)
+			r.With(app.requireAuthenticatedUser).Post("/", app.createArticleHandler)
+			r.Get("/{slug}", app.getArticleHandler)
+			r.Get("/{slug}/related", app.relatedArticlesHandler)
+			r.With(app.requireAuthenticatedUser).Put("/{slug}", app.updateArticleHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/{slug}", app.deleteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/{slug}/favorite", app.favoriteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/{slug}/favorite", app.unfavoriteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/id/{id}/favorite", app.favoriteArticleByIDHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/id/{id}/favorite", app.unfavoriteArticleByIDHandler)
+			r.With(app.requireAuthenticatedUser).Post("/{slug}/comments", app.createCommentHandler)
+			r.Get("/{slug}/comments", app.getCommentsHandler)
+			r.Get("/{slug}/comments/{id}", app.getCommentHandler)
+			r.With(app.requireAuthenticatedUser).Put("/{slug}/comments/{id}", app.updateCommentHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/{slug}/comments/{id}", app.deleteCommentHandler)
+		})
+
+		r.Post("/comments/batch", app.batchCommentsHandler)
+
+		r.Get("/tags", app.getTagsHandler)
 	})
 
-	r.Get("/tags", app.getTagsHandler)
+	app.routeIndex = flattenRoutes(r)
 
 	return r
 }
+
+// flattenRoutes returns a mux with every route of the router registered directly on it,
+// with no-op handlers. chi can't tell which methods are routed for a path that ends at a
+// mounted subrouter (such as /articles), but it can on a flat mux.
+func flattenRoutes(routes chi.Routes) *chi.Mux {
+	flat := chi.NewMux()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	// Walk never fails here since the callback doesn't return errors
+	_ = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		flat.Method(method, route, noop)
+		// Subrouter index routes are walked as "/prefix/" but also serve "/prefix"
+		if trimmed := strings.TrimSuffix(route, "/"); trimmed != "" && trimmed != route {
+			flat.Method(method, trimmed, noop)
+		}
+		return nil
+	})
+
+	return flat
+}
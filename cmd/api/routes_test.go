@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodNotAllowed_AllowHeader(t *testing.T) {
+	t.Parallel()
+
+	// Method mismatches are resolved by the router alone, so no database is needed
+	app := &application{
+		config: appConfig{env: "testing"},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	router := app.routes()
+
+	testCases := []struct {
+		method    string
+		path      string
+		wantAllow string
+	}{
+		{http.MethodDelete, "/articles", "GET, POST"},
+		{http.MethodPost, "/articles/some-slug", "GET, PUT, DELETE"},
+		{http.MethodPut, "/articles/some-slug/favorite", "POST, DELETE"},
+		{http.MethodPost, "/healthcheck", "GET"},
+		{http.MethodGet, "/users/login", "POST"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+			assert.Equal(t, tc.wantAllow, rr.Header().Get("Allow"))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,8 @@ import (
 )
 
 // serve is the entry point for the HTTP server.
+// When a TLS certificate and key are configured it serves HTTPS (with HTTP/2 enabled
+// automatically), otherwise it serves plain HTTP.
 func (app *application) serve() error {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.config.port),
@@ -23,6 +26,13 @@ func (app *application) serve() error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	// Load the certificate up front so that a bad cert/key fails at startup
+	tlsConfig, err := loadTLSConfig(app.config.tls.certFile, app.config.tls.keyFile)
+	if err != nil {
+		return err
+	}
+	srv.TLSConfig = tlsConfig
+
 	// Create a shutdownError channel. We will use this to receive any errors returned
 	// by the graceful Shutdown() function.
 	shutdownError := make(chan error)
@@ -51,7 +61,12 @@ func (app *application) serve() error {
 
 	app.logger.Info("starting server", "properties", app.config)
 
-	err := srv.ListenAndServe()
+	if srv.TLSConfig != nil {
+		// The certificate is already in TLSConfig, so no files are passed here
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -64,3 +79,24 @@ func (app *application) serve() error {
 	app.logger.Info("stopped server", "addr", srv.Addr)
 	return nil
 }
+
+// loadTLSConfig loads the certificate and key and returns a TLS config serving them.
+// It returns a nil config if neither file is configured, and an error if only one is.
+func loadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both -tls-cert and -tls-key must be provided to enable TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load TLS certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// snakeCaseEnvelope returns the envelope with every JSON object key converted from
+// camelCase to snake_case, for clients that expect snake_case field names.
+//
+// Maps placed directly in an envelope are keyed by data rather than by field name (such
+// as the usernames of the following status response, or the article slugs of the comment
+// batch response), so their keys are kept as they are and only their values converted.
+func snakeCaseEnvelope(env envelope) (map[string]any, error) {
+	converted := make(map[string]any, len(env))
+	for key, value := range env {
+		if nested, ok := value.(envelope); ok {
+			nestedConverted, err := snakeCaseEnvelope(nested)
+			if err != nil {
+				return nil, err
+			}
+			converted[snakeCaseKey(key)] = nestedConverted
+			continue
+		}
+
+		generic, err := toGenericJSON(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if dataKeyed, ok := generic.(map[string]any); ok && reflect.ValueOf(value).Kind() == reflect.Map {
+			for k, v := range dataKeyed {
+				dataKeyed[k] = snakeCaseKeys(v)
+			}
+			converted[snakeCaseKey(key)] = dataKeyed
+			continue
+		}
+
+		converted[snakeCaseKey(key)] = snakeCaseKeys(generic)
+	}
+
+	return converted, nil
+}
+
+// toGenericJSON round-trips v through JSON into maps, slices and scalars, so that it is
+// marshaled exactly as it would be otherwise. Numbers are kept as json.Number to avoid
+// losing precision.
+func toGenericJSON(v any) (any, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var generic any
+	err = dec.Decode(&generic)
+	return generic, err
+}
+
+// snakeCaseKeys converts the keys of every JSON object within v to snake_case.
+func snakeCaseKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, value := range v {
+			converted[snakeCaseKey(key)] = snakeCaseKeys(value)
+		}
+		return converted
+	case []any:
+		for i, value := range v {
+			v[i] = snakeCaseKeys(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// snakeCaseKey converts a camelCase key to snake_case, e.g. favoritesCount to
+// favorites_count. Keys that are already snake_case are returned unchanged.
+func snakeCaseKey(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
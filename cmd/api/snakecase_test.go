@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnakeCaseKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]string{
+		"favoritesCount": "favorites_count",
+		"createdAt":      "created_at",
+		"id":             "id",
+		"system_info":    "system_info",
+		"isEmpty":        "is_empty",
+	}
+
+	for key, want := range testcases {
+		assert.Equal(t, want, snakeCaseKey(key), key)
+	}
+}
+
+func TestSnakeCaseEnvelope(t *testing.T) {
+	t.Parallel()
+
+	type author struct {
+		Username string `json:"username"`
+	}
+	type article struct {
+		TagList        []string `json:"tagList"`
+		FavoritesCount int      `json:"favoritesCount"`
+		Author         author   `json:"author"`
+	}
+
+	converted, err := snakeCaseEnvelope(envelope{
+		"articles":      []article{{TagList: []string{"go"}, FavoritesCount: 2, Author: author{Username: "alice"}}},
+		"articlesCount": 1,
+		"meta":          envelope{"isEmpty": false},
+		"following":     map[string]bool{"JohnDoe": true},
+	})
+	require.NoError(t, err)
+
+	js, err := json.Marshal(converted)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"articles": [{"tag_list": ["go"], "favorites_count": 2, "author": {"username": "alice"}}],
+		"articles_count": 1,
+		"meta": {"is_empty": false},
+		"following": {"JohnDoe": true}
+	}`, string(js))
+}
@@ -1,11 +1,70 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/manas-solves/realworld-backend/internal/data"
 )
 
+// getTagsHandler returns all tags. Tag clouds poll this endpoint, so the response carries
+// an ETag derived from the tags, and a matching If-None-Match gets 304 with no body.
 func (app *application) getTagsHandler(w http.ResponseWriter, r *http.Request) {
-	tags, err := app.modelStore.Tags.GetAll()
+	tags, err := app.modelStore.Tags.GetAll(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	etag, err := contentETag(tags)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+	err = app.writeJSON(w, http.StatusOK, envelope{"tags": tags}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// contentETag returns a strong ETag computed from the JSON encoding of v.
+func contentETag(v any) (string, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(js)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// authorTagsHandler lists the distinct tags used across an author's articles, with the
+// number of the author's articles using each tag.
+func (app *application) authorTagsHandler(w http.ResponseWriter, r *http.Request) {
+	author, err := app.modelStore.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	tags, err := app.modelStore.Tags.GetByAuthor(r.Context(), author.ID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"io"
 	"net/http"
 	"testing"
+
+	"github.com/manas-solves/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type getTagsResponse struct {
@@ -63,3 +68,163 @@ func TestGetTagsHandler_NoTags(t *testing.T) {
 	}
 	testHandler(t, ts, testcases...)
 }
+
+func TestGetTagsHandler_CaseInsensitiveTags(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	createArticle(t, ts, aliceToken, "Mixed Case One", "First", "Body one", []string{"Golang", "Testing"})
+	createArticle(t, ts, aliceToken, "Mixed Case Two", "Second", "Body two", []string{"golang"})
+	createArticle(t, ts, aliceToken, "Mixed Case Three", "Third", "Body three", []string{"GOLANG"})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Mixed-case tags unify into one tag",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/tags",
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: getTagsResponse{
+				Tags: []string{"golang", "testing"},
+			},
+		},
+		{
+			name:                   "Duplicate tags differing only in case are rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article":{"title":"Dup","description":"Dup","body":"Dup","tagList":["Go","go"]}}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"TagList must not contain duplicate tags"},
+			},
+		},
+	}
+	testHandler(t, ts, testcases...)
+
+	for _, tag := range []string{"golang", "GoLang", "GOLANG"} {
+		t.Run("Filtering by "+tag+" is case-insensitive", func(t *testing.T) {
+			res, err := ts.executeRequest(http.MethodGet, "/articles?tag="+tag, "", nil)
+			require.NoError(t, err)
+			defer res.Body.Close() //nolint: errcheck
+
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var response struct {
+				Articles      []data.Article     `json:"articles"`
+				ArticlesCount int                `json:"articlesCount"`
+				Pagination    paginationMetadata `json:"pagination"`
+			}
+			readJsonResponse(t, res.Body, &response)
+			assert.Equal(t, 3, response.ArticlesCount)
+			for _, article := range response.Articles {
+				assert.Contains(t, article.TagList, "golang")
+			}
+		})
+	}
+}
+
+func TestGetTagsHandler_ETag(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	createArticle(t, ts, aliceToken, "Go Tutorial", "Learn Go", "Body", []string{"golang"})
+
+	getTags := func(ifNoneMatch string) *http.Response {
+		t.Helper()
+
+		var headers map[string]string
+		if ifNoneMatch != "" {
+			headers = map[string]string{"If-None-Match": ifNoneMatch}
+		}
+		res, err := ts.executeRequest(http.MethodGet, "/tags", "", headers)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() }) //nolint: errcheck
+		return res
+	}
+
+	res := getTags("")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	t.Run("Unchanged tags return 304", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{etag, "W/" + etag, `"stale", ` + etag, "*"} {
+			res := getTags(ifNoneMatch)
+			assert.Equal(t, http.StatusNotModified, res.StatusCode, ifNoneMatch)
+			assert.Equal(t, etag, res.Header.Get("ETag"))
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Empty(t, body)
+		}
+	})
+
+	t.Run("New tag invalidates the ETag", func(t *testing.T) {
+		createArticle(t, ts, aliceToken, "Postgres Tutorial", "Learn Postgres", "Body", []string{"postgres"})
+
+		res := getTags(etag)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotEqual(t, etag, res.Header.Get("ETag"))
+
+		var resp getTagsResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.Equal(t, []string{"golang", "postgres"}, resp.Tags)
+	})
+}
+
+func TestAuthorTagsHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "carol", "carol@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	createArticle(t, ts, aliceToken, "Go Basics", "Intro", "Body", []string{"golang", "backend"})
+	createArticle(t, ts, aliceToken, "Go Testing", "Tests", "Body", []string{"golang", "testing"})
+	createArticle(t, ts, aliceToken, "Go Services", "Services", "Body", []string{"backend", "golang"})
+	// Bob's tags must not be counted for Alice
+	createArticle(t, ts, bobToken, "Frontend", "Web", "Body", []string{"frontend", "golang"})
+
+	type authorTagsResponse struct {
+		Tags []data.TagCount `json:"tags"`
+	}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Distinct tags with counts",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/alice/tags",
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: authorTagsResponse{
+				Tags: []data.TagCount{
+					{Tag: "golang", Count: 3},
+					{Tag: "backend", Count: 2},
+					{Tag: "testing", Count: 1},
+				},
+			},
+		},
+		{
+			name:                   "Author without articles",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/carol/tags",
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse:           authorTagsResponse{Tags: []data.TagCount{}},
+		},
+		{
+			name:                   "Unknown user",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/nobody/tags",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
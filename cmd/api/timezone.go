@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	// Embed the time zone database so zone lookups don't depend on the host having one
+	_ "time/tzdata"
+)
+
+// loadTimezone loads an IANA time zone, such as America/New_York. Unlike time.LoadLocation
+// it rejects the empty name and "Local", which would expose the server's own time zone.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return time.LoadLocation(name)
+}
+
+// readTimezone returns the time zone that timestamps should also be shown in, taken from
+// the tz query parameter or else the configured default time zone. It returns nil when
+// neither is set, and ok is false when the parameter names an unknown time zone.
+func (app *application) readTimezone(r *http.Request) (loc *time.Location, ok bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return app.defaultTimezone, true
+	}
+
+	loc, err := loadTimezone(name)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// timezoneErrorMessage is the validation error for an unknown tz query parameter.
+const timezoneErrorMessage = "tz must be a known time zone, such as America/New_York"
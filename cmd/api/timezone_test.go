@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		valid bool
+	}{
+		{"America/New_York", true},
+		{"Asia/Kolkata", true},
+		{"UTC", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus_Mons", false},
+		{"../../etc/passwd", false},
+	}
+
+	for _, tc := range testCases {
+		loc, err := loadTimezone(tc.name)
+		if tc.valid {
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.name, loc.String())
+		} else {
+			assert.Error(t, err, tc.name)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/manas-solves/realworld-backend/internal/data"
 	"github.com/manas-solves/realworld-backend/internal/validator"
@@ -42,7 +46,13 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = app.modelStore.Users.Insert(&user)
+	// Reject disposable email providers if a blocklist is configured
+	if app.blockedDomains.Blocked(user.Email) {
+		app.failedValidationResponse(w, r, []string{"email domain is not allowed"})
+		return
+	}
+
+	err = app.modelStore.Users.Insert(r.Context(), &user)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
@@ -64,7 +74,13 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 	user.Token = token
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"user": user}, nil)
+	response, err := app.withArticlesCount(r.Context(), &user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"user": response}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -93,7 +109,7 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, err := app.modelStore.Users.GetByEmail(input.User.Email)
+	user, err := app.modelStore.Users.GetByEmail(r.Context(), input.User.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -114,6 +130,22 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Upgrade the hash to the configured algorithm and cost while the plaintext is at hand.
+	// Hashing is deliberately slow, so it runs in the background to not delay the login, and
+	// a failure only delays the upgrade to the next login.
+	if user.Password.NeedsRehash() {
+		// Rehash a copy, since RehashPassword updates the hash of the user it is given
+		rehashUser := *user
+		plaintext := input.User.Password
+		// The rehash outlives the request, so it mustn't be cancelled with it
+		ctx := context.WithoutCancel(r.Context())
+		app.background(func() {
+			if err := app.modelStore.Users.RehashPassword(ctx, &rehashUser, plaintext); err != nil {
+				app.logError(r, err)
+			}
+		})
+	}
+
 	// Generate a new JWT token for the user.
 	token, err := app.jwtMaker.CreateToken(user.ID, app.config.jwtMaker.accessDuration)
 	if err != nil {
@@ -122,25 +154,230 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 	user.Token = token
 
+	user, err = app.withArticlesCount(r.Context(), user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if app.config.authCookie {
+		http.SetCookie(w, app.authCookie(token, int(app.config.jwtMaker.accessDuration.Seconds())))
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// authCookieName is the name of the cookie holding the token when cookie auth is enabled.
+const authCookieName = "conduit_token"
+
+// authCookie returns the auth cookie holding the given token for maxAge seconds. The
+// cookie is kept away from scripts and cross-site requests, so browser clients can
+// authenticate without storing the token themselves.
+func (app *application) authCookie(token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     authCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// logoutUserHandler clears the auth cookie. Tokens sent in the Authorization header are
+// stateless, so there is nothing to do for them.
+func (app *application) logoutUserHandler(w http.ResponseWriter, r *http.Request) {
+	if app.config.authCookie {
+		http.SetCookie(w, app.authCookie("", -1))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateTokenHandler reports whether the presented token is valid and when it expires,
+// responding 401 for missing, invalid or expired tokens. Only the token itself is checked
+// unless checkUser=true is passed, in which case the token's user must also still exist.
+func (app *application) validateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	checkUser := false
+	if value := r.URL.Query().Get("checkUser"); value != "" {
+		var err error
+		checkUser, err = strconv.ParseBool(value)
+		if err != nil {
+			app.failedValidationResponse(w, r, []string{"checkUser must be a boolean value"})
+			return
+		}
+	}
+
+	tokenString, claims, failure := app.verifyRequestToken(r)
+	if failure != "" {
+		app.logAuthFailure(r, failure)
+		app.invalidAuthenticationTokenResponse(w, r)
+		return
+	}
+	if tokenString == "" {
+		app.invalidAuthenticationTokenResponse(w, r)
+		return
+	}
+
+	if checkUser {
+		_, err := app.modelStore.Users.GetByID(r.Context(), claims.UserID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				app.logAuthFailure(r, authFailureUnknownUser)
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	response := envelope{"valid": true}
+	if claims.ExpiresAt != nil {
+		response["expiresAt"] = claims.ExpiresAt.Time.UTC()
+	}
+
+	err := app.writeJSON(w, http.StatusOK, response, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// followersHandler lists the profiles of the users following a user.
+func (app *application) followersHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollowsHandler(w, r, app.modelStore.Users.ListFollowers)
+}
+
+// followingHandler lists the profiles of the users a user follows.
+func (app *application) followingHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollowsHandler(w, r, app.modelStore.Users.ListFollowing)
+}
+
+// listFollowsHandler writes a page of the profiles returned by list for the user named in
+// the URL, with the same default page size as the article lists and a configurable maximum.
+func (app *application) listFollowsHandler(w http.ResponseWriter, r *http.Request,
+	list func(ctx context.Context, userID int64, limit, offset int, viewerID int64) ([]data.Profile, int, error)) {
+	pagination := app.readPagination(r, min(20, app.config.maxFollowsPageSize), app.config.maxFollowsPageSize)
+
+	v := validator.New()
+	pagination.Validate(v, app.config.maxPageOffset)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.modelStore.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	profiles, totalCount, err := list(r.Context(), user.ID, pagination.Limit, pagination.Offset, app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"profiles":   profiles,
+		"totalCount": totalCount,
+		"pagination": pagination.Metadata(totalCount),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 // getCurrentUserHandler returns the currently authenticated user.
 func (app *application) getCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
-	user := app.contextGetUser(r)
-	err := app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	user, err := app.withArticlesCount(r.Context(), app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// notificationCountsHandler returns how many users followed the authenticated user, and
+// how many comments and favorites other users made on their articles, after the optional
+// since query parameter (an RFC 3339 timestamp). Clients pass the time of their last check,
+// and all events are counted when it is omitted.
+func (app *application) notificationCountsHandler(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			app.failedValidationResponse(w, r, []string{"Since must be an RFC 3339 timestamp"})
+			return
+		}
+	}
+
+	counts, err := app.modelStore.Users.NotificationCounts(r.Context(), app.contextGetUser(r).ID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notifications": counts}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// withArticlesCount returns the user with the number of articles they authored set, when
+// the count is enabled in the config. It sets the count on a copy, since the user may be
+// shared through the user cache.
+func (app *application) withArticlesCount(ctx context.Context, user *data.User) (*data.User, error) {
+	if !app.config.userArticlesCount {
+		return user, nil
+	}
+
+	count, err := app.modelStore.Articles.CountByAuthor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	withCount := *user
+	withCount.ArticlesCount = &count
+	return &withCount, nil
+}
+
+// meAlias can be used in place of a username to refer to the authenticated user.
+const meAlias = data.UsernameAliasMe
+
 // getProfileHandler returns a user's profile, including follow status.
+// The "me" alias returns the authenticated user's own profile.
 func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	targetUser, err := app.modelStore.Users.GetByUsername(username)
+
+	if username == meAlias {
+		user := app.contextGetUser(r)
+		if user.IsAnonymous() {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		err := app.writeJSON(w, http.StatusOK, envelope{"profile": user.ToProfile(false)}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	targetUser, err := app.modelStore.Users.GetByUsername(r.Context(), username)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -153,7 +390,7 @@ func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request
 	var following bool
 	user := app.contextGetUser(r)
 	if !user.IsAnonymous() {
-		following, _ = app.modelStore.Users.IsFollowing(user.ID, targetUser.ID)
+		following, _ = app.modelStore.Users.IsFollowing(r.Context(), user.ID, targetUser.ID)
 	}
 
 	profile := targetUser.ToProfile(following)
@@ -166,7 +403,7 @@ func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request
 // followUserHandler lets the authenticated user follow another user.
 func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	targetUser, err := app.modelStore.Users.GetByUsername(username)
+	targetUser, err := app.modelStore.Users.GetByUsername(r.Context(), username)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -180,9 +417,16 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 		app.failedValidationResponse(w, r, []string{"cannot follow yourself"})
 		return
 	}
-	err = app.modelStore.Users.FollowUser(user.ID, targetUser.ID)
+	_, err = app.modelStore.Users.FollowUser(r.Context(), user.ID, targetUser.ID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrFollowLimitExceeded):
+			app.failedValidationResponse(w, r, []string{fmt.Sprintf("cannot follow more than %d users", app.config.maxFollows)})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 	profile := targetUser.ToProfile(true)
@@ -192,10 +436,156 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// maxFollowingStatusUsernames caps how many usernames can be checked in a single
+// following-status or bulk follow request.
+const maxFollowingStatusUsernames = 100
+
+// Per-username outcomes reported by bulkFollowHandler.
+const (
+	bulkFollowFollowed      = "followed"
+	bulkFollowAlready       = "already"
+	bulkFollowNotFound      = "not_found"
+	bulkFollowSelf          = "self"
+	bulkFollowLimitExceeded = "limit_exceeded"
+	bulkFollowError         = "error"
+)
+
+// bulkFollowResult is the outcome of following a single username in a bulk follow request.
+type bulkFollowResult struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+}
+
+// bulkFollowHandler follows each of the requested usernames on a best-effort basis and
+// responds 207 Multi-Status with the outcome for every username, in request order. A
+// username that can't be followed doesn't stop the others from being followed.
+func (app *application) bulkFollowHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Usernames []string `json:"usernames"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(input.Usernames) > 0, "usernames must be provided")
+	v.Check(len(input.Usernames) <= maxFollowingStatusUsernames,
+		fmt.Sprintf("usernames must not contain more than %d entries", maxFollowingStatusUsernames))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	results := make([]bulkFollowResult, 0, len(input.Usernames))
+	for _, username := range input.Usernames {
+		status, err := app.followForBulk(r.Context(), user, username)
+		if err != nil {
+			// Earlier follows are already committed, so report the failure for this
+			// username rather than failing the whole request
+			app.logError(r, err)
+			status = bulkFollowError
+		}
+		results = append(results, bulkFollowResult{Username: username, Status: status})
+	}
+
+	err = app.writeJSON(w, http.StatusMultiStatus, envelope{"results": results}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// followForBulk makes user follow the named user and returns the bulk follow status
+// describing what happened. Only unexpected errors are returned as errors.
+func (app *application) followForBulk(ctx context.Context, user *data.User, username string) (string, error) {
+	if username == meAlias {
+		return bulkFollowSelf, nil
+	}
+
+	targetUser, err := app.modelStore.Users.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return bulkFollowNotFound, nil
+		}
+		return "", err
+	}
+	if targetUser.ID == user.ID {
+		return bulkFollowSelf, nil
+	}
+
+	created, err := app.modelStore.Users.FollowUser(ctx, user.ID, targetUser.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			// The user was deleted after the lookup
+			return bulkFollowNotFound, nil
+		case errors.Is(err, data.ErrFollowLimitExceeded):
+			return bulkFollowLimitExceeded, nil
+		default:
+			return "", err
+		}
+	}
+	if !created {
+		return bulkFollowAlready, nil
+	}
+
+	return bulkFollowFollowed, nil
+}
+
+// followingStatusHandler reports whether the authenticated user follows each of the
+// requested usernames.
+func (app *application) followingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Usernames []string `json:"usernames"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(input.Usernames) > 0, "usernames must be provided")
+	v.Check(len(input.Usernames) <= maxFollowingStatusUsernames,
+		fmt.Sprintf("usernames must not contain more than %d entries", maxFollowingStatusUsernames))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	// Resolve the "me" alias to the authenticated user, whom they can never follow
+	usernames := make([]string, 0, len(input.Usernames))
+	for _, username := range input.Usernames {
+		if username != meAlias {
+			usernames = append(usernames, username)
+		}
+	}
+
+	following, err := app.modelStore.Users.GetFollowingStatus(r.Context(), user.ID, usernames)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(usernames) != len(input.Usernames) {
+		following[meAlias] = false
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"following": following}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 // unfollowUserHandler lets the authenticated user unfollow another user.
 func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	targetUser, err := app.modelStore.Users.GetByUsername(username)
+	targetUser, err := app.modelStore.Users.GetByUsername(r.Context(), username)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -205,7 +595,12 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	user := app.contextGetUser(r)
-	err = app.modelStore.Users.UnfollowUser(user.ID, targetUser.ID)
+	// Unfollowing yourself is a no-op unless strict mode makes it mirror following yourself
+	if app.config.strictSelfUnfollow && user.ID == targetUser.ID {
+		app.failedValidationResponse(w, r, []string{"cannot unfollow yourself"})
+		return
+	}
+	err = app.modelStore.Users.UnfollowUser(r.Context(), user.ID, targetUser.ID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -222,11 +617,12 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 
 	var input struct {
 		User struct {
-			Email    *string `json:"email"`
-			Password *string `json:"password"`
-			Username *string `json:"username"`
-			Bio      *string `json:"bio"`
-			Image    *string `json:"image"`
+			Email           *string `json:"email"`
+			Password        *string `json:"password"`
+			Username        *string `json:"username"`
+			Bio             *string `json:"bio"`
+			Image           *string `json:"image"`
+			FavoritesPublic *bool   `json:"favoritesPublic"`
 		} `json:"user"`
 	}
 
@@ -249,6 +645,9 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 	if input.User.Image != nil {
 		updatedUser.Image = *input.User.Image
 	}
+	if input.User.FavoritesPublic != nil {
+		updatedUser.FavoritesPublic = *input.User.FavoritesPublic
+	}
 	if input.User.Password != nil {
 		err := updatedUser.Password.Set(*input.User.Password)
 		if err != nil {
@@ -263,7 +662,7 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = app.modelStore.Users.Update(&updatedUser)
+	err = app.modelStore.Users.Update(r.Context(), &updatedUser)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/manas-solves/realworld-backend/internal/auth"
+	"github.com/manas-solves/realworld-backend/internal/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,11 +26,12 @@ type userResponse struct {
 }
 
 type user struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Image    string `json:"image"`
-	Bio      string `json:"bio"`
-	Token    string `json:"token"`
+	Username        string `json:"username"`
+	Email           string `json:"email"`
+	Image           string `json:"image"`
+	Bio             string `json:"bio"`
+	FavoritesPublic bool   `json:"favoritesPublic"`
+	Token           string `json:"token"`
 }
 
 type profile struct {
@@ -84,11 +97,12 @@ func TestRegisterUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusCreated,
 			wantResponse: userResponse{
 				User: user{
-					Username: "Bob",
-					Email:    "bob@gmail.com",
-					Image:    "",
-					Bio:      "",
-					Token:    "dummy-token",
+					Username:        "Bob",
+					Email:           "bob@gmail.com",
+					Image:           "",
+					Bio:             "",
+					FavoritesPublic: true,
+					Token:           "dummy-token",
 				},
 			},
 		},
@@ -116,6 +130,14 @@ func TestRegisterUserHandler(t *testing.T) {
 				Errors: []string{"username must be provided", "password must be at least 8 bytes long"},
 			},
 		},
+		{
+			name:                   "Reserved username",
+			requestBody:            `{"user":{"username":"Me", "email":"me@gmail.com", "password":"pa55word1234"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{`username "me" is reserved`},
+			},
+		},
 		{
 			name:                   "Duplicate email",
 			requestBody:            `{"user":{"username":"alice_new", "email":"alice@gmail.com", "password":"pa55word1234"}}`,
@@ -198,11 +220,12 @@ func TestLoginUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: userResponse{
 				User: user{
-					Username: "Alice",
-					Email:    "alice@gmail.com",
-					Token:    "dummy-token",
-					Image:    "",
-					Bio:      "",
+					Username:        "Alice",
+					Email:           "alice@gmail.com",
+					Token:           "dummy-token",
+					Image:           "",
+					Bio:             "",
+					FavoritesPublic: true,
 				},
 			},
 		},
@@ -304,9 +327,10 @@ func TestGetCurrentUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: userResponse{
 				User: user{
-					Username: "Bob",
-					Email:    "bob@example.com",
-					Token:    tokenBob,
+					Username:        "Bob",
+					Email:           "bob@example.com",
+					Token:           tokenBob,
+					FavoritesPublic: true,
 				},
 			},
 		},
@@ -318,9 +342,10 @@ func TestGetCurrentUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: userResponse{
 				User: user{
-					Username: "Alice",
-					Email:    "alice@example.com",
-					Token:    tokenAlice,
+					Username:        "Alice",
+					Email:           "alice@example.com",
+					Token:           tokenAlice,
+					FavoritesPublic: true,
 				},
 			},
 		},
@@ -546,6 +571,42 @@ func TestUnfollowUserHandler(t *testing.T) {
 	testHandler(t, ts, testCases...)
 }
 
+func TestUnfollowUserHandler_StrictSelfUnfollow(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.strictSelfUnfollow = true
+	})
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+	followUser(t, ts, bobToken, "Alice")
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "user cannot unfollow themselves",
+			requestUrlPath:         "/profiles/Bob/follow",
+			requestMethodType:      http.MethodDelete,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"cannot unfollow yourself"},
+			},
+		},
+		{
+			name:                   "unfollowing others is unaffected",
+			requestUrlPath:         "/profiles/Alice/follow",
+			requestMethodType:      http.MethodDelete,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{Username: "Alice", Following: false},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
 func TestUpdateUserHandler(t *testing.T) {
 	t.Parallel()
 	ts := newTestServer(t)
@@ -648,3 +709,1305 @@ func TestUpdateUserHandler(t *testing.T) {
 	}
 	testHandler(t, ts, testCases...)
 }
+
+func TestUpdateUserHandler_DuplicateUsernameOrEmail(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "username already taken",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          authHeader,
+			requestBody:            `{"user":{"username":"Bob"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"a user with this username already exists"},
+			},
+		},
+		{
+			name:                   "username taken with different case",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          authHeader,
+			requestBody:            `{"user":{"username":"bob"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"a user with this username already exists"},
+			},
+		},
+		{
+			name:                   "email already taken",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          authHeader,
+			requestBody:            `{"user":{"email":"bob@example.com"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"a user with this email address already exists"},
+			},
+		},
+		{
+			name:                   "keeping own username succeeds",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          authHeader,
+			requestBody:            `{"user":{"username":"Alice","bio":"still alice"}}`,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestFollowUserHandler_MaxFollows(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxFollows = 2
+	})
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	registerUser(t, ts, "Charlie", "charlie@example.com", "charliepassword")
+	registerUser(t, ts, "Dave", "dave@example.com", "davepassword")
+
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	// Following up to the cap succeeds
+	followUser(t, ts, aliceToken, "Bob")
+	followUser(t, ts, aliceToken, "Charlie")
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "following beyond the cap is rejected",
+			requestUrlPath:         "/profiles/Dave/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"cannot follow more than 2 users"},
+			},
+		},
+		{
+			name:                   "re-following an already followed user at the cap succeeds",
+			requestUrlPath:         "/profiles/Bob/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{Username: "Bob", Following: true},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestFollowUserHandler_MaxFollowsConcurrent(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxFollows = 2
+	})
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	targets := []string{"Bob", "Charlie", "Dave", "Erin", "Frank", "Grace"}
+	for _, name := range targets {
+		registerUser(t, ts, name, strings.ToLower(name)+"@example.com", "password123")
+	}
+
+	// Follow everyone at once; the limit must hold even though the requests race
+	statuses := make(chan int, len(targets))
+	var wg sync.WaitGroup
+	for _, name := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := ts.executeRequest(http.MethodPost, "/profiles/"+name+"/follow", "", authHeader)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			res.Body.Close() //nolint: errcheck
+			statuses <- res.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 2, http.StatusUnprocessableEntity: 4}, counts)
+
+	var following int
+	err := ts.openDB(t).QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM follows f JOIN users u ON f.follower_id = u.id WHERE u.username = 'Alice'").Scan(&following)
+	require.NoError(t, err)
+	assert.Equal(t, 2, following)
+}
+
+func TestFollowingStatusHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	registerUser(t, ts, "Charlie", "charlie@example.com", "charliepassword")
+
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	followUser(t, ts, aliceToken, "Bob")
+
+	type followingStatusResponse struct {
+		Following map[string]bool `json:"following"`
+	}
+
+	tooMany := make([]string, maxFollowingStatusUsernames+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`"user%d"`, i)
+	}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "mix of followed, not followed and unknown usernames",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Bob","Charlie","Nobody"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: followingStatusResponse{
+				Following: map[string]bool{"Bob": true, "Charlie": false, "Nobody": false},
+			},
+		},
+		{
+			name:                   "usernames are matched case-insensitively",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["bob"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: followingStatusResponse{
+				Following: map[string]bool{"bob": true},
+			},
+		},
+		{
+			name:                   "empty batch is rejected",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":[]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"usernames must be provided"},
+			},
+		},
+		{
+			name:                   "oversized batch is rejected",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":[` + strings.Join(tooMany, ",") + `]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{fmt.Sprintf("usernames must not contain more than %d entries", maxFollowingStatusUsernames)},
+			},
+		},
+		{
+			name:                   "anonymous user is rejected",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"usernames":["Bob"]}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse: errorResponse{
+				Errors: []string{"invalid or missing authentication token"},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestBulkFollowHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxFollows = 3
+	})
+
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave", "Eve"} {
+		registerUser(t, ts, name, strings.ToLower(name)+"@example.com", strings.ToLower(name)+"password")
+	}
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	followUser(t, ts, aliceToken, "Bob")
+
+	type bulkFollowResponse struct {
+		Results []bulkFollowResult `json:"results"`
+	}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "mixed results are reported per username in order",
+			requestUrlPath:         "/profiles/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Bob","Charlie","Nobody","Alice","me","Charlie"]}`,
+			wantResponseStatusCode: http.StatusMultiStatus,
+			wantResponse: bulkFollowResponse{
+				Results: []bulkFollowResult{
+					{Username: "Bob", Status: bulkFollowAlready},
+					{Username: "Charlie", Status: bulkFollowFollowed},
+					{Username: "Nobody", Status: bulkFollowNotFound},
+					{Username: "Alice", Status: bulkFollowSelf},
+					{Username: "me", Status: bulkFollowSelf},
+					{Username: "Charlie", Status: bulkFollowAlready},
+				},
+			},
+		},
+		{
+			name:                   "follows past the limit are reported without failing the rest",
+			requestUrlPath:         "/profiles/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Dave","Eve","Bob"]}`,
+			wantResponseStatusCode: http.StatusMultiStatus,
+			wantResponse: bulkFollowResponse{
+				Results: []bulkFollowResult{
+					{Username: "Dave", Status: bulkFollowFollowed},
+					{Username: "Eve", Status: bulkFollowLimitExceeded},
+					{Username: "Bob", Status: bulkFollowAlready},
+				},
+			},
+		},
+		{
+			name:                   "the follows were written",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Bob","Charlie","Dave","Eve"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: struct {
+				Following map[string]bool `json:"following"`
+			}{
+				Following: map[string]bool{"Bob": true, "Charlie": true, "Dave": true, "Eve": false},
+			},
+		},
+		{
+			name:                   "empty batch is rejected",
+			requestUrlPath:         "/profiles/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":[]}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"usernames must be provided"},
+			},
+		},
+		{
+			name:                   "anonymous user is rejected",
+			requestUrlPath:         "/profiles/follow",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"usernames":["Bob"]}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse: errorResponse{
+				Errors: []string{"invalid or missing authentication token"},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+// deletingUserStore deletes every user it looks up by username right after returning it,
+// simulating a user being deleted between the handler's lookup and its write.
+type deletingUserStore struct {
+	data.UserStoreInterface
+	db *pgxpool.Pool
+}
+
+func (s deletingUserStore) GetByUsername(ctx context.Context, username string) (*data.User, error) {
+	user, err := s.UserStoreInterface.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	return user, err
+}
+
+func TestFollowUserHandler_TargetDeletedConcurrently(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+
+	db := ts.openDB(t)
+	ts.app.modelStore.Users = deletingUserStore{UserStoreInterface: ts.app.modelStore.Users, db: db}
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "following a user deleted after lookup returns 404",
+		requestUrlPath:         "/profiles/Bob/follow",
+		requestMethodType:      http.MethodPost,
+		requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+		wantResponseStatusCode: http.StatusNotFound,
+		wantResponse: errorResponse{
+			Errors: []string{"the requested resource could not be found"},
+		},
+	})
+}
+
+// failingFollowUserStore fails every follow of the user with ID failFollowedID.
+type failingFollowUserStore struct {
+	data.UserStoreInterface
+	failFollowedID int64
+}
+
+func (s failingFollowUserStore) FollowUser(ctx context.Context, followerID, followedID int64) (bool, error) {
+	if followedID == s.failFollowedID {
+		return false, errors.New("connection reset")
+	}
+	return s.UserStoreInterface.FollowUser(ctx, followerID, followedID)
+}
+
+func TestBulkFollowHandler_PartialFailure(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		registerUser(t, ts, name, strings.ToLower(name)+"@example.com", strings.ToLower(name)+"password")
+	}
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	charlie, err := ts.app.modelStore.Users.GetByUsername(context.Background(), "Charlie")
+	require.NoError(t, err)
+	users := ts.app.modelStore.Users
+	ts.app.modelStore.Users = failingFollowUserStore{UserStoreInterface: users, failFollowedID: charlie.ID}
+
+	type bulkFollowResponse struct {
+		Results []bulkFollowResult `json:"results"`
+	}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "a failing follow is reported without failing the rest",
+			requestUrlPath:         "/profiles/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Bob","Charlie","Dave"]}`,
+			wantResponseStatusCode: http.StatusMultiStatus,
+			wantResponse: bulkFollowResponse{
+				Results: []bulkFollowResult{
+					{Username: "Bob", Status: bulkFollowFollowed},
+					{Username: "Charlie", Status: bulkFollowError},
+					{Username: "Dave", Status: bulkFollowFollowed},
+				},
+			},
+		},
+		{
+			name:                   "only the failed follow is missing",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["Bob","Charlie","Dave"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: struct {
+				Following map[string]bool `json:"following"`
+			}{
+				Following: map[string]bool{"Bob": true, "Charlie": false, "Dave": true},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestGetProfileHandler_MeAlias(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	followUser(t, ts, aliceToken, "Bob")
+
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+	createArticle(t, ts, aliceToken, "Alice Article", "By Alice", "Body", nil)
+	createArticle(t, ts, bobToken, "Bob Article", "By Bob", "Body", nil)
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "authenticated me returns own profile",
+			requestUrlPath:         "/profiles/me",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{Username: "Alice", Following: false},
+			},
+		},
+		{
+			name:                   "anonymous me is unauthorized",
+			requestUrlPath:         "/profiles/me",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse: errorResponse{
+				Errors: []string{"invalid or missing authentication token"},
+			},
+		},
+		{
+			name:                   "me in following-status resolves to not following",
+			requestUrlPath:         "/profiles/following-status",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          authHeader,
+			requestBody:            `{"usernames":["me","Bob"]}`,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: struct {
+				Following map[string]bool `json:"following"`
+			}{Following: map[string]bool{"me": false, "Bob": true}},
+		},
+		{
+			name:                   "anonymous author=me filter is unauthorized",
+			requestUrlPath:         "/articles?author=me",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	}
+	testHandler(t, ts, testCases...)
+
+	t.Run("author=me lists own articles", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/articles?author=me", "", authHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article     `json:"articles"`
+			ArticlesCount int                `json:"articlesCount"`
+			Pagination    paginationMetadata `json:"pagination"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		require.Equal(t, 1, response.ArticlesCount)
+		assert.Equal(t, "Alice", response.Articles[0].Author.Username)
+	})
+}
+
+// TestDefaultAvatarURL is not parallel because the default image is package-level state
+// in the data package.
+func TestDefaultAvatarURL(t *testing.T) {
+	const defaultImage = "https://static.example.com/default-avatar.png"
+	data.SetDefaultImage(defaultImage)
+	t.Cleanup(func() { data.SetDefaultImage("") })
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+
+	// Bob sets an image, then clears it back to empty
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+	res, err := ts.executeRequest(http.MethodPut, "/user", `{"user":{"image":"https://example.com/bob.png"}}`, bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "current user without image gets the default",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var got userResponse
+				readJsonResponse(t, res.Body, &got)
+				assert.Equal(t, defaultImage, got.User.Image)
+			},
+		},
+		{
+			name:                   "profile without image gets the default",
+			requestUrlPath:         "/profiles/Alice",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{Username: "Alice", Image: defaultImage},
+			},
+		},
+		{
+			name:                   "profile with image keeps its own image",
+			requestUrlPath:         "/profiles/Bob",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{Username: "Bob", Image: "https://example.com/bob.png"},
+			},
+		},
+		{
+			name:                   "clearing the image falls back to the default",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"image":""}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var got userResponse
+				readJsonResponse(t, res.Body, &got)
+				assert.Equal(t, defaultImage, got.User.Image)
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+// TestHTTPSOnlyImageURLs is not parallel because the https-only setting is package-level
+// state in the data package.
+func TestHTTPSOnlyImageURLs(t *testing.T) {
+	data.SetRequireHTTPSImages(true)
+	t.Cleanup(func() { data.SetRequireHTTPSImages(false) })
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "http image is rejected",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"image":"http://example.com/alice.png"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"image must be an https URL"},
+			},
+		},
+		{
+			name:                   "relative image is rejected",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"image":"//example.com/alice.png"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"image must be an https URL"},
+			},
+		},
+		{
+			name:                   "https image is accepted",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"image":"https://example.com/alice.png"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var got userResponse
+				readJsonResponse(t, res.Body, &got)
+				assert.Equal(t, "https://example.com/alice.png", got.User.Image)
+			},
+		},
+		{
+			name:                   "empty image is accepted",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"image":""}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestAuthenticationFlow_UserCacheDisabled(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.userCache.enabled = false
+	})
+	require.Nil(t, ts.app.userCache)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	authHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "current user is resolved without the cache",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: userResponse{
+				User: user{Username: "Alice", Email: "alice@example.com", FavoritesPublic: true, Token: aliceToken},
+			},
+		},
+		{
+			name:                   "update is visible on the next request",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestBody:            `{"user":{"bio":"uncached"}}`,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		{
+			name:                   "current user reflects the update",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: userResponse{
+				User: user{Username: "Alice", Email: "alice@example.com", Bio: "uncached", FavoritesPublic: true, Token: aliceToken},
+			},
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestRegisterUserHandler_SortValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, sorted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sorted=%t", sorted), func(t *testing.T) {
+			t.Parallel()
+			ts := newTestServer(t, func(cfg *appConfig) {
+				cfg.sortValidationErrors = sorted
+			})
+
+			// Checks run username first, then password
+			want := []string{"username must be provided", "password must be at least 8 bytes long"}
+			if sorted {
+				want = []string{"password must be at least 8 bytes long", "username must be provided"}
+			}
+
+			testHandler(t, ts, handlerTestcase{
+				name:                   "Multiple validation errors",
+				requestUrlPath:         "/users",
+				requestMethodType:      http.MethodPost,
+				requestBody:            `{"user":{"username":"", "email":"abc@gmail.com", "password":"123"}}`,
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse:           errorResponse{Errors: want},
+			})
+		})
+	}
+}
+
+func TestRegisterUserHandler_BlockedEmailDomains(t *testing.T) {
+	t.Parallel()
+
+	blocklistFile := filepath.Join(t.TempDir(), "blocked-domains.txt")
+	require.NoError(t, os.WriteFile(blocklistFile, []byte("# Disposable providers\nmailinator.com\n\n  Trashmail.COM  \n"), 0o600))
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.blockedDomainsFile = blocklistFile
+	})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Blocked domain",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users",
+			requestBody:            `{"user":{"username":"spammer","email":"spammer@mailinator.com","password":"password123"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"email domain is not allowed"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Blocked domain matched case-insensitively",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users",
+			requestBody:            `{"user":{"username":"spammer2","email":"spammer@TrashMail.com","password":"password123"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"email domain is not allowed"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Subdomain of a blocked domain is not blocked",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users",
+			requestBody:            `{"user":{"username":"subdomain","email":"someone@mail.mailinator.com","password":"password123"}}`,
+			wantResponseStatusCode: http.StatusCreated,
+		},
+		handlerTestcase{
+			name:                   "Allowed domain",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users",
+			requestBody:            `{"user":{"username":"alice","email":"alice@example.com","password":"password123"}}`,
+			wantResponseStatusCode: http.StatusCreated,
+		},
+	)
+}
+
+func TestUserHandlers_ArticlesCount(t *testing.T) {
+	t.Parallel()
+
+	type userWithCount struct {
+		user
+		ArticlesCount *int `json:"articlesCount"`
+	}
+	currentUser := func(t *testing.T, ts *testServer, token string) userWithCount {
+		t.Helper()
+
+		var response struct {
+			User userWithCount `json:"user"`
+		}
+		body := getRawBody(t, ts, "/user", map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, json.Unmarshal(body, &response))
+		return response.User
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.userArticlesCount = true
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		got := currentUser(t, ts, token)
+		require.NotNil(t, got.ArticlesCount)
+		assert.Equal(t, 0, *got.ArticlesCount)
+
+		createArticle(t, ts, token, "First", "First article", "Body", nil)
+		createArticle(t, ts, token, "Second", "Second article", "Body", nil)
+
+		got = currentUser(t, ts, token)
+		require.NotNil(t, got.ArticlesCount)
+		assert.Equal(t, 2, *got.ArticlesCount)
+		assert.Equal(t, "alice", got.Username)
+
+		// Login reports the count too
+		res, err := ts.executeRequest(http.MethodPost, "/users/login", `{"user": {"email": "alice@example.com", "password": "password123"}}`, nil)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var loginResponse struct {
+			User userWithCount `json:"user"`
+		}
+		readJsonResponse(t, res.Body, &loginResponse)
+		require.NotNil(t, loginResponse.User.ArticlesCount)
+		assert.Equal(t, 2, *loginResponse.User.ArticlesCount)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t)
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+		createArticle(t, ts, token, "First", "First article", "Body", nil)
+
+		assert.Nil(t, currentUser(t, ts, token).ArticlesCount)
+	})
+}
+
+func TestHandlers_RejectTrailingJSONValues(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	token := loginUser(t, ts, "alice@example.com", "password123")
+	authHeader := map[string]string{"Authorization": "Token " + token}
+	articleLocation := createArticle(t, ts, token, "Trailing", "Trailing data", "Body", nil)
+
+	wantResponse := errorResponse{Errors: []string{"body must only contain a single JSON value"}}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Register",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users",
+			requestBody:            `{"user": {"username": "bob", "email": "bob@example.com", "password": "password123"}}{"user": {}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+		{
+			name:                   "Login",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/users/login",
+			requestBody:            `{"user": {"email": "alice@example.com", "password": "password123"}} []`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+		{
+			name:                   "Update user",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/user",
+			requestHeader:          authHeader,
+			requestBody:            `{"user": {"bio": "hello"}}{"user": {"bio": "again"}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+		{
+			name:                   "Create article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"title": "A", "description": "d", "body": "b"}}{"article": {}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+		{
+			name:                   "Update article",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         articleLocation,
+			requestHeader:          authHeader,
+			requestBody:            `{"article": {"body": "new"}} "trailing"`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+		{
+			name:                   "Create comment",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          authHeader,
+			requestBody:            `{"comment": {"body": "hi"}}{"comment": {"body": "again"}}`,
+			wantResponseStatusCode: http.StatusBadRequest,
+			wantResponse:           wantResponse,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+func TestAuthCookie(t *testing.T) {
+	t.Parallel()
+
+	login := func(t *testing.T, ts *testServer) *http.Response {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, "/users/login", `{"user": {"email": "alice@example.com", "password": "password123"}}`, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		return res
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t, func(cfg *appConfig) {
+			cfg.authCookie = true
+		})
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+
+		res := login(t, ts)
+		defer res.Body.Close() //nolint: errcheck
+		cookies := res.Cookies()
+		require.Len(t, cookies, 1)
+		cookie := cookies[0]
+		assert.Equal(t, authCookieName, cookie.Name)
+		assert.NotEmpty(t, cookie.Value)
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.Positive(t, cookie.MaxAge)
+
+		// The cookie alone authenticates the request
+		cookieHeader := map[string]string{"Cookie": cookie.String()}
+		res, err := ts.executeRequest(http.MethodGet, "/user", "", cookieHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var response userResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, "alice", response.User.Username)
+
+		// Logging out expires the cookie
+		res, err = ts.executeRequest(http.MethodPost, "/users/logout", "", cookieHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+		cookies = res.Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, authCookieName, cookies[0].Name)
+		assert.Empty(t, cookies[0].Value)
+		assert.Negative(t, cookies[0].MaxAge)
+
+		// An invalid cookie is rejected like an invalid header token
+		res, err = ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Cookie": authCookieName + "=not-a-token"})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newTestServer(t)
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+		token := loginUser(t, ts, "alice@example.com", "password123")
+
+		res := login(t, ts)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Empty(t, res.Cookies())
+
+		// The cookie is ignored, so the request is anonymous
+		res, err := ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Cookie": authCookieName + "=" + token})
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestNotificationCountsHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	location := createArticle(t, ts, aliceToken, "Noticed", "Gets attention", "Body", nil)
+	before := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	followUser(t, ts, bobToken, "alice")
+	createCommentHelper(t, ts, bobToken, location, "Nice one")
+	favoriteArticleHelper(t, ts, bobToken, strings.TrimPrefix(location, "/articles/"))
+	// Alice's own activity on her article is not a notification
+	createCommentHelper(t, ts, aliceToken, location, "Thanks")
+	after := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+
+	type notificationsResponse struct {
+		Notifications data.NotificationCounts `json:"notifications"`
+	}
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "All events",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: notificationsResponse{
+				Notifications: data.NotificationCounts{NewFollowers: 1, NewComments: 1, NewFavorites: 1},
+			},
+		},
+		{
+			name:                   "Since before the events",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count?since=" + before,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: notificationsResponse{
+				Notifications: data.NotificationCounts{NewFollowers: 1, NewComments: 1, NewFavorites: 1},
+			},
+		},
+		{
+			name:                   "Since after the events",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count?since=" + after,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse:           notificationsResponse{},
+		},
+		{
+			name:                   "Nothing for the follower",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse:           notificationsResponse{},
+		},
+		{
+			name:                   "Invalid since",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count?since=yesterday",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Since must be an RFC 3339 timestamp"},
+			},
+		},
+		{
+			name:                   "Anonymous",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications/count",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+func TestFollowersAndFollowingHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, func(cfg *appConfig) {
+		cfg.maxFollowsPageSize = 5
+	})
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	numFollowers := 8
+	for i := 1; i <= numFollowers; i++ {
+		username := "follower" + strconv.Itoa(i)
+		registerUser(t, ts, username, username+"@example.com", "password123")
+		followUser(t, ts, loginUser(t, ts, username+"@example.com", "password123"), "alice")
+	}
+	// Alice follows one of her followers back
+	followUser(t, ts, aliceToken, "follower1")
+
+	type followsResponse struct {
+		Profiles   []profile          `json:"profiles"`
+		TotalCount int                `json:"totalCount"`
+		Pagination paginationMetadata `json:"pagination"`
+	}
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Page size capped",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/alice/followers?limit=50",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response followsResponse
+				readJsonResponse(t, res.Body, &response)
+
+				assert.Len(t, response.Profiles, 5)
+				assert.Equal(t, numFollowers, response.TotalCount)
+				assert.Equal(t, paginationMetadata{Limit: 5, Offset: 0, HasMore: true}, response.Pagination)
+				// Most recent follow first
+				assert.Equal(t, "follower8", response.Profiles[0].Username)
+			},
+		},
+		{
+			name:                   "Last page",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/alice/followers?offset=5",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response followsResponse
+				readJsonResponse(t, res.Body, &response)
+
+				require.Len(t, response.Profiles, 3)
+				assert.Equal(t, numFollowers, response.TotalCount)
+				assert.False(t, response.Pagination.HasMore)
+				assert.Equal(t, "follower1", response.Profiles[2].Username)
+			},
+		},
+		{
+			name:                   "Following status relative to the viewer",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/alice/followers?offset=7",
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: followsResponse{
+				Profiles:   []profile{{Username: "follower1", Following: true}},
+				TotalCount: numFollowers,
+				Pagination: paginationMetadata{Limit: 5, Offset: 7, HasMore: false},
+			},
+		},
+		{
+			name:                   "Following",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/alice/following",
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: followsResponse{
+				Profiles:   []profile{{Username: "follower1", Following: false}},
+				TotalCount: 1,
+				Pagination: paginationMetadata{Limit: 5, Offset: 0, HasMore: false},
+			},
+		},
+		{
+			name:                   "Unknown user",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/profiles/nobody/followers",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+func TestValidateTokenHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	maker, err := auth.NewJWTMaker(ts.app.config.jwtMaker.secretKey, ts.app.config.jwtMaker.issuer)
+	require.NoError(t, err)
+	expiredToken, err := maker.CreateToken(1, -time.Minute)
+	require.NoError(t, err)
+	// The token is valid, but its user doesn't exist
+	unknownUserToken, err := maker.CreateToken(9999, time.Hour)
+	require.NoError(t, err)
+
+	type validateResponse struct {
+		Valid     bool      `json:"valid"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	assertValid := func(t *testing.T, res *http.Response) {
+		var got validateResponse
+		readJsonResponse(t, res.Body, &got)
+		assert.True(t, got.Valid)
+		assert.WithinDuration(t, time.Now().Add(ts.app.config.jwtMaker.accessDuration), got.ExpiresAt, time.Minute)
+	}
+
+	unauthorized := errorResponse{Errors: []string{"invalid or missing authentication token"}}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Valid token",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks:       assertValid,
+		},
+		handlerTestcase{
+			name:                   "Valid token of an unknown user is not looked up by default",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			requestHeader:          map[string]string{"Authorization": "Token " + unknownUserToken},
+			wantResponseStatusCode: http.StatusOK,
+		},
+		handlerTestcase{
+			name:                   "Valid token with user check",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate?checkUser=true",
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks:       assertValid,
+		},
+		handlerTestcase{
+			name:                   "Unknown user with user check",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate?checkUser=true",
+			requestHeader:          map[string]string{"Authorization": "Token " + unknownUserToken},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		handlerTestcase{
+			name:                   "Expired token",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			requestHeader:          map[string]string{"Authorization": "Token " + expiredToken},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		handlerTestcase{
+			name:                   "Malformed token",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			requestHeader:          map[string]string{"Authorization": "Token not-a-jwt"},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		handlerTestcase{
+			name:                   "Malformed header",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			requestHeader:          map[string]string{"Authorization": "Bearer " + aliceToken},
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		handlerTestcase{
+			name:                   "Missing token",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate",
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           unauthorized,
+		},
+		handlerTestcase{
+			name:                   "Invalid checkUser",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/users/token/validate?checkUser=maybe",
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"checkUser must be a boolean value"}},
+		},
+	)
+}
+
+// TestLoginUserHandler_MigratesPasswordHash is not parallel because the password hashing
+// settings are package-level state in the data package.
+func TestLoginUserHandler_MigratesPasswordHash(t *testing.T) {
+	ts := newTestServer(t)
+	db := ts.openDB(t)
+
+	passwordHash := func(t *testing.T) string {
+		var hash []byte
+		err := db.QueryRow(context.Background(), "SELECT password_hash FROM users WHERE email = $1", "alice@example.com").Scan(&hash)
+		require.NoError(t, err)
+		return string(hash)
+	}
+
+	// Register while new passwords are hashed with bcrypt
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	assert.True(t, strings.HasPrefix(passwordHash(t), "$2a$"))
+
+	data.SetPasswordHashing(data.PasswordHashing{
+		Algorithm: data.PasswordHashArgon2id,
+		Argon2:    data.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1},
+	})
+	t.Cleanup(func() { data.SetPasswordHashing(data.DefaultPasswordHashing) })
+
+	// The bcrypt hash still verifies, and is replaced with an argon2id hash in the background
+	loginUser(t, ts, "alice@example.com", "alicepassword")
+	ts.app.wg.Wait()
+	assert.True(t, strings.HasPrefix(passwordHash(t), "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	// The migrated hash verifies on the next login, and is kept
+	migrated := passwordHash(t)
+	loginUser(t, ts, "alice@example.com", "alicepassword")
+	ts.app.wg.Wait()
+	assert.Equal(t, migrated, passwordHash(t))
+
+	res, err := ts.executeRequest(http.MethodPost, "/users/login", `{"user":{"email":"alice@example.com","password":"wrongpassword"}}`, nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+// TestLoginUserHandler_UpgradesBcryptCost is not parallel because the password hashing
+// settings are package-level state in the data package.
+func TestLoginUserHandler_UpgradesBcryptCost(t *testing.T) {
+	ts := newTestServer(t)
+	db := ts.openDB(t)
+
+	passwordHash := func(t *testing.T) string {
+		var hash []byte
+		err := db.QueryRow(context.Background(), "SELECT password_hash FROM users WHERE email = $1", "alice@example.com").Scan(&hash)
+		require.NoError(t, err)
+		return string(hash)
+	}
+
+	lowCost := data.DefaultPasswordHashing
+	lowCost.BcryptCost = 4
+	data.SetPasswordHashing(lowCost)
+	t.Cleanup(func() { data.SetPasswordHashing(data.DefaultPasswordHashing) })
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	assert.True(t, strings.HasPrefix(passwordHash(t), "$2a$04$"))
+
+	higherCost := data.DefaultPasswordHashing
+	higherCost.BcryptCost = 5
+	data.SetPasswordHashing(higherCost)
+
+	// The login succeeds with the low-cost hash, which is upgraded in the background
+	loginUser(t, ts, "alice@example.com", "alicepassword")
+	ts.app.wg.Wait()
+	upgraded := passwordHash(t)
+	assert.True(t, strings.HasPrefix(upgraded, "$2a$05$"))
+
+	// The upgraded hash verifies, and is kept since it uses the configured cost
+	loginUser(t, ts, "alice@example.com", "alicepassword")
+	ts.app.wg.Wait()
+	assert.Equal(t, upgraded, passwordHash(t))
+}
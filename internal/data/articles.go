@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/manas-solves/realworld-backend/internal/validator"
 	sq "github.com/Masterminds/squirrel"
@@ -16,19 +19,25 @@ import (
 )
 
 type Article struct {
-	ID             int64     `json:"-"`
-	Slug           string    `json:"slug"`
-	Title          string    `json:"title"`
-	Description    string    `json:"description"`
-	Body           string    `json:"body,omitempty"`
-	TagList        []string  `json:"tagList"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
-	FavoritesCount int       `json:"favoritesCount"`
-	Favorited      bool      `json:"favorited"`
-	AuthorID       int64     `json:"-"`
-	Author         Profile   `json:"author"`
-	Version        int       `json:"-"`
+	ID             int64      `json:"-"`
+	Slug           string     `json:"slug"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
+	Body           string     `json:"body,omitempty"`
+	BodyType       string     `json:"bodyType"`
+	BodyHTML       string     `json:"bodyHtml,omitempty"`
+	CoverImage     string     `json:"coverImage"`
+	TagList        []string   `json:"tagList"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	CreatedAtLocal *time.Time `json:"createdAtLocal,omitempty"` // Only set by Localize
+	UpdatedAtLocal *time.Time `json:"updatedAtLocal,omitempty"` // Only set by Localize
+	FavoritesCount int        `json:"favoritesCount"`
+	Favorited      bool       `json:"favorited"`
+	AuthorID       int64      `json:"-"`
+	Author         Profile    `json:"author"`
+	Version        int        `json:"-"`
+	NewTags        []string   `json:"-"` // Tags first created by this article, only set by InsertAndReturn
 }
 
 func ValidateArticle(v *validator.Validator, article *Article) {
@@ -40,24 +49,37 @@ func ValidateArticle(v *validator.Validator, article *Article) {
 	v.Check(validator.NotEmptyOrWhitespace(article.Body),
 		"Body must not be empty or whitespace only")
 
+	v.Check(validator.PermittedValue(article.BodyType, BodyTypes...),
+		fmt.Sprintf("BodyType must be one of %s", strings.Join(BodyTypes, ", ")))
+
 	v.Check(validator.Unique(article.TagList), "TagList must not contain duplicate tags")
+
+	// Cover images are always https, regardless of the user image setting; empty means none
+	if article.CoverImage != "" {
+		v.Check(validator.HTTPSURL(article.CoverImage), "CoverImage must be an https URL")
+	}
 }
 
-// GenerateSlug generates a URL-friendly slug from the article title.
-func (a *Article) GenerateSlug() {
-	slug := strings.ToLower(a.Title)
-	slug = strings.ReplaceAll(slug, " ", "-")
+// Article and comment body types, telling clients how to render the body.
+const (
+	BodyTypeMarkdown = "markdown"
+	BodyTypePlain    = "plain"
+)
 
-	// Remove non-alphanumeric characters except hyphens
-	reg := regexp.MustCompile(`[^a-z0-9\-]`)
-	slug = reg.ReplaceAllString(slug, "")
+// BodyTypes lists the permitted article and comment body types.
+var BodyTypes = []string{BodyTypeMarkdown, BodyTypePlain}
 
-	// Remove multiple consecutive hyphens
-	reg = regexp.MustCompile(`-+`)
-	slug = reg.ReplaceAllString(slug, "-")
+// GenerateSlug generates a URL-friendly slug from the article title. The part derived
+// from the title is cut to at most maxBaseLength bytes (0 means no limit) before the
+// random suffix is appended.
+func (a *Article) GenerateSlug(maxBaseLength int) {
+	slug := slugify(a.Title)
 
-	// Trim hyphens from start and end
-	slug = strings.Trim(slug, "-")
+	// Truncate long titles, without leaving a dangling hyphen where a word was cut off.
+	// The slug is ASCII at this point, so cutting at a byte offset is safe.
+	if maxBaseLength > 0 && len(slug) > maxBaseLength {
+		slug = strings.TrimRight(slug[:maxBaseLength], "-")
+	}
 
 	// Append a random string to ensure uniqueness
 	slug = slug + "-" + randomString(7)
@@ -65,6 +87,29 @@ func (a *Article) GenerateSlug() {
 	a.Slug = slug
 }
 
+var (
+	// slugInvalidCharsRX matches the characters slugify drops.
+	slugInvalidCharsRX = regexp.MustCompile(`[^a-z0-9\-]`)
+	// slugHyphensRX matches runs of hyphens that slugify collapses into one.
+	slugHyphensRX = regexp.MustCompile(`-+`)
+)
+
+// slugify converts a title into the URL-friendly base of a slug: lowercase letters, digits
+// and single hyphens between words, with no leading or trailing hyphens.
+func slugify(title string) string {
+	slug := strings.ToLower(title)
+	slug = strings.ReplaceAll(slug, " ", "-")
+
+	// Remove non-alphanumeric characters except hyphens
+	slug = slugInvalidCharsRX.ReplaceAllString(slug, "")
+
+	// Remove multiple consecutive hyphens
+	slug = slugHyphensRX.ReplaceAllString(slug, "-")
+
+	// Trim hyphens from start and end
+	return strings.Trim(slug, "-")
+}
+
 // RandomString generates a cryptographically secure random string of specified length
 // using lowercase letters and numbers. Uses crypto/rand for thread-safety and better randomness.
 func randomString(length int) string {
@@ -85,6 +130,68 @@ func randomString(length int) string {
 	return string(result)
 }
 
+// Localize sets CreatedAtLocal and UpdatedAtLocal to the article's timestamps in loc,
+// leaving the UTC timestamps as they are. It does nothing when loc is nil.
+func (a *Article) Localize(loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	createdAt, updatedAt := a.CreatedAt.In(loc), a.UpdatedAt.In(loc)
+	a.CreatedAtLocal, a.UpdatedAtLocal = &createdAt, &updatedAt
+}
+
+// DefaultDescription sets the article's description to an excerpt of at most maxLength
+// characters of its body when the description is empty or whitespace only. It does nothing
+// when maxLength is 0.
+func (a *Article) DefaultDescription(maxLength int) {
+	if maxLength > 0 && strings.TrimSpace(a.Description) == "" {
+		a.Description = Excerpt(a.Body, maxLength)
+	}
+}
+
+// Excerpt returns the start of body with runs of whitespace collapsed to single spaces,
+// cut to at most maxLength characters. Text is cut at the last word boundary within the
+// limit, falling back to a hard cut for a single overlong word, and an ellipsis is
+// appended when anything was cut. The ellipsis counts towards maxLength.
+func Excerpt(body string, maxLength int) string {
+	if maxLength <= 0 {
+		return ""
+	}
+
+	words := strings.Fields(body)
+	text := strings.Join(words, " ")
+
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+
+	// Leave room for the ellipsis
+	limit := maxLength - 1
+	cut := string(runes[:limit])
+	// Only keep whole words, unless the first word alone is longer than the limit
+	if runes[limit] != ' ' {
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			cut = cut[:i]
+		}
+	}
+
+	return strings.TrimRight(cut, " ") + "…"
+}
+
+// NormalizeTags lowercases and trims the article's tags so that tags are
+// case-insensitive throughout (storage, the tags table and filtering).
+func (a *Article) NormalizeTags() {
+	for i, tag := range a.TagList {
+		a.TagList[i] = NormalizeTag(tag)
+	}
+}
+
+// NormalizeTag returns the canonical, lowercased form of a tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
 // SortTags sorts the article's tags alphabetically for consistent ordering
 func (a *Article) SortTags() {
 	if len(a.TagList) > 0 {
@@ -92,40 +199,129 @@ func (a *Article) SortTags() {
 	}
 }
 
+// DedupeTags removes repeated tags from the article, keeping the first occurrence of each
+// so that the remaining tags stay in the order they were given.
+func (a *Article) DedupeTags() {
+	seen := make(map[string]bool, len(a.TagList))
+	deduped := a.TagList[:0]
+	for _, tag := range a.TagList {
+		if !seen[tag] {
+			seen[tag] = true
+			deduped = append(deduped, tag)
+		}
+	}
+	a.TagList = deduped
+}
+
+// Policies for duplicate tags in a new article's tag list.
+const (
+	// TagPolicyReject fails validation when the tag list contains duplicates, and
+	// stores the tags sorted alphabetically.
+	TagPolicyReject = "reject"
+	// TagPolicyDedupe silently drops duplicates, and stores the tags in the order they
+	// were given.
+	TagPolicyDedupe = "dedupe"
+)
+
+// TagPolicies lists the supported duplicate tag policies.
+var TagPolicies = []string{TagPolicyReject, TagPolicyDedupe}
+
+// ErrSelfFavorite is returned when self-favoriting is forbidden and a user tries to
+// favorite their own article.
+var ErrSelfFavorite = errors.New("self favorite")
+
+// ErrArticleLimitExceeded is returned when an author who already owns the maximum number
+// of articles tries to create another one.
+var ErrArticleLimitExceeded = errors.New("article limit exceeded")
+
 type ArticleStore struct {
-	db      *pgxpool.Pool
-	timeout time.Duration
+	db                 *pgxpool.Pool
+	timeout            time.Duration
+	retry              RetryPolicy
+	forbidSelfFavorite bool
+	maxSlugLength      int
+	maxArticles        int
+	tagPolicy          string
+	// computedFavoritesCount counts favorites on read rather than maintaining the
+	// favorites_count column on every favorite, avoiding contention on hot articles.
+	computedFavoritesCount bool
+}
+
+// favoritesCountExpr returns the SQL expression for the favorites count of article a.
+func (s *ArticleStore) favoritesCountExpr() string {
+	if s.computedFavoritesCount {
+		return "(SELECT COUNT(*) FROM favorites fc WHERE fc.article_id = a.id)"
+	}
+	return "a.favorites_count"
 }
 
 // InsertAndReturn inserts an article and populates it with database-generated fields and author details.
 // Modifies the input article object in place and uses currentUser from context instead of querying the database.
-func (s *ArticleStore) InsertAndReturn(article *Article, currentUser *User) (*Article, error) {
-	article.GenerateSlug()
-	article.SortTags()
+// Tags are sorted alphabetically unless the dedupe tag policy is in effect, which keeps their given order.
+// If an article limit is configured, it returns ErrArticleLimitExceeded when the author
+// already owns the maximum number of articles.
+func (s *ArticleStore) InsertAndReturn(ctx context.Context, article *Article, currentUser *User) (*Article, error) {
+	article.GenerateSlug(s.maxSlugLength)
+	article.NormalizeTags()
+	if s.tagPolicy != TagPolicyDedupe {
+		article.SortTags()
+	}
+	// Always store an empty array rather than NULL when no tags are given
+	article.TagList = emptyIfNil(article.TagList)
 
 	// Insert the article - only return fields we don't already have
 	query := `
-		INSERT INTO articles (slug, title, description, body, tag_list, author_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO articles (slug, title, description, body, body_type, cover_image, tag_list, author_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at, favorites_count, version
 	`
 
 	args := []any{
 		article.Slug, article.Title, article.Description, article.Body,
-		article.TagList, article.AuthorID,
+		article.BodyType, article.CoverImage, article.TagList, article.AuthorID,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	// Scan only the fields we don't already have into the input object
-	err := s.db.QueryRow(ctx, query, args...).Scan(
-		&article.ID,
-		&article.CreatedAt,
-		&article.UpdatedAt,
-		&article.FavoritesCount,
-		&article.Version,
-	)
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if s.maxArticles > 0 {
+			// Lock the author so that concurrent creates by the same user are checked
+			// against the limit one at a time
+			var count int
+			err := tx.QueryRow(ctx, `
+				SELECT (SELECT COUNT(*) FROM articles WHERE author_id = u.id)
+				FROM users u
+				WHERE u.id = $1
+				FOR NO KEY UPDATE`, article.AuthorID).Scan(&count)
+			if err != nil {
+				return err
+			}
+			if count >= s.maxArticles {
+				return ErrArticleLimitExceeded
+			}
+		}
+
+		// Scan only the fields we don't already have into the input object
+		err := tx.QueryRow(ctx, query, args...).Scan(
+			&article.ID,
+			&article.CreatedAt,
+			&article.UpdatedAt,
+			&article.FavoritesCount,
+			&article.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    article.AuthorID,
+			Action:     AuditActionCreate,
+			TargetType: AuditTargetArticle,
+			TargetID:   article.ID,
+			TargetSlug: article.Slug,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -137,8 +333,10 @@ func (s *ArticleStore) InsertAndReturn(article *Article, currentUser *User) (*Ar
 	article.Favorited = false
 
 	// Insert tags into tags table synchronously
+	article.NewTags = []string{}
 	if len(article.TagList) > 0 {
-		if err := s.InsertTags(article.TagList...); err != nil {
+		article.NewTags, err = s.InsertTags(ctx, article.TagList...)
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -148,15 +346,14 @@ func (s *ArticleStore) InsertAndReturn(article *Article, currentUser *User) (*Ar
 
 // GetIDBySlug retrieves just the article ID by its slug.
 // This is a lightweight alternative to GetBySlug when only the ID is needed.
-func (s *ArticleStore) GetIDBySlug(slug string) (int64, error) {
+func (s *ArticleStore) GetIDBySlug(ctx context.Context, slug string) (int64, error) {
 	query := `SELECT id FROM articles WHERE slug = $1`
 
 	var articleID int64
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, slug).Scan(&articleID)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, slug).Scan(&articleID)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, ErrRecordNotFound
@@ -167,11 +364,47 @@ func (s *ArticleStore) GetIDBySlug(slug string) (int64, error) {
 	return articleID, nil
 }
 
+// WasDeleted reports whether an article with the given slug has been deleted, based on the
+// delete entries in the audit log. It does not check whether the slug is in use again.
+func (s *ArticleStore) WasDeleted(ctx context.Context, slug string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM audit_log
+			WHERE target_slug = $1 AND action = $2 AND target_type = $3
+		)`
+
+	var deleted bool
+
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, slug, AuditActionDelete, AuditTargetArticle).Scan(&deleted)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// CountByAuthor returns the number of articles owned by the given author.
+func (s *ArticleStore) CountByAuthor(ctx context.Context, authorID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM articles WHERE author_id = $1`
+
+	var count int
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, authorID).Scan(&count)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // GetBySlug retrieves an article by its slug.
-func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, error) {
+func (s *ArticleStore) GetBySlug(ctx context.Context, slug string, currentUser *User) (*Article, error) {
 	query := `
-		SELECT a.id, a.slug, a.title, a.description, a.body, a.tag_list, a.created_at, a.updated_at, 
-		       a.favorites_count, a.version, u.id, u.username, u.bio, u.image
+		SELECT a.id, a.slug, a.title, a.description, a.body, a.body_type, a.cover_image, a.tag_list, a.created_at, a.updated_at, 
+		       ` + s.favoritesCountExpr() + `, a.version, u.id, u.username, u.bio, u.image
 		FROM articles a
 		JOIN users u ON a.author_id = u.id
 		WHERE a.slug = $1
@@ -180,25 +413,26 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 	var article Article
 	var author Profile
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, slug).Scan(
-		&article.ID,
-		&article.Slug,
-		&article.Title,
-		&article.Description,
-		&article.Body,
-		&article.TagList,
-		&article.CreatedAt,
-		&article.UpdatedAt,
-		&article.FavoritesCount,
-		&article.Version,
-		&article.AuthorID,
-		&author.Username,
-		&author.Bio,
-		&author.Image,
-	)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, slug).Scan(
+			&article.ID,
+			&article.Slug,
+			&article.Title,
+			&article.Description,
+			&article.Body,
+			&article.BodyType,
+			&article.CoverImage,
+			&article.TagList,
+			&article.CreatedAt,
+			&article.UpdatedAt,
+			&article.FavoritesCount,
+			&article.Version,
+			&article.AuthorID,
+			&author.Username,
+			&author.Bio,
+			&author.Image,
+		)
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -209,10 +443,11 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 	}
 
 	article.Author = author
+	article.TagList = emptyIfNil(article.TagList)
 
 	// Check if the current user has favorited the article
 	if !currentUser.IsAnonymous() {
-		favorited, err := s.checkArticleFavorited(article.ID, currentUser.ID)
+		favorited, err := s.checkArticleFavorited(ctx, article.ID, currentUser.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -221,14 +456,129 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 	return &article, nil
 }
 
-func (s *ArticleStore) checkArticleFavorited(articleID, userID int64) (bool, error) {
+// GetBySlugWithComments retrieves an article by slug together with its latest comments,
+// newest first and at most commentLimit of them (0 means no limit), in a single round trip.
+// It also returns the article's total number of comments, so that callers can tell whether
+// the comments were cut off. The favorited flag of the article and the following flags of
+// the article and comment authors are set for currentUser.
+func (s *ArticleStore) GetBySlugWithComments(ctx context.Context, slug string, currentUser *User, commentLimit int) (*Article, []Comment, int, error) {
+	articleQuery := `
+		SELECT a.id, a.slug, a.title, a.description, a.body, a.body_type, a.cover_image, a.tag_list, a.created_at, a.updated_at,
+		       ` + s.favoritesCountExpr() + `, a.version, u.id, u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM favorites f WHERE f.article_id = a.id AND f.user_id = $2),
+		       EXISTS(SELECT 1 FROM follows fo WHERE fo.follower_id = $2 AND fo.followed_id = u.id)
+		FROM articles a
+		JOIN users u ON a.author_id = u.id
+		WHERE a.slug = $1
+	`
+
+	commentsQuery := `
+		SELECT c.id, c.body, c.body_type, c.article_id, c.author_id, c.created_at, c.updated_at,
+		       u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM follows fo WHERE fo.follower_id = $2 AND fo.followed_id = c.author_id),
+		       COUNT(*) OVER()
+		FROM comments c
+		JOIN articles a ON c.article_id = a.id
+		JOIN users u ON c.author_id = u.id
+		WHERE a.slug = $1
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $3
+	`
+
+	// The anonymous user's ID matches no rows, so its flags are all false
+	viewerID := currentUser.ID
+
+	// NULL means no limit
+	var rowLimit *int
+	if commentLimit > 0 {
+		rowLimit = &commentLimit
+	}
+
+	var article Article
+	var comments []Comment
+	var commentsCount int
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		batch := &pgx.Batch{}
+		batch.Queue(articleQuery, slug, viewerID)
+		batch.Queue(commentsQuery, slug, viewerID, rowLimit)
+
+		results := s.db.SendBatch(ctx, batch)
+		defer results.Close()
+
+		article = Article{}
+		err := results.QueryRow().Scan(
+			&article.ID,
+			&article.Slug,
+			&article.Title,
+			&article.Description,
+			&article.Body,
+			&article.BodyType,
+			&article.CoverImage,
+			&article.TagList,
+			&article.CreatedAt,
+			&article.UpdatedAt,
+			&article.FavoritesCount,
+			&article.Version,
+			&article.AuthorID,
+			&article.Author.Username,
+			&article.Author.Bio,
+			&article.Author.Image,
+			&article.Favorited,
+			&article.Author.Following,
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := results.Query()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		comments, commentsCount = nil, 0
+		for rows.Next() {
+			var comment Comment
+			err := rows.Scan(
+				&comment.ID,
+				&comment.Body,
+				&comment.BodyType,
+				&comment.ArticleID,
+				&comment.AuthorID,
+				&comment.CreatedAt,
+				&comment.UpdatedAt,
+				&comment.Author.Username,
+				&comment.Author.Bio,
+				&comment.Author.Image,
+				&comment.Author.Following,
+				&commentsCount,
+			)
+			if err != nil {
+				return err
+			}
+			comments = append(comments, comment)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, 0, ErrRecordNotFound
+		}
+		return nil, nil, 0, err
+	}
+
+	article.TagList = emptyIfNil(article.TagList)
+
+	return &article, emptyIfNil(comments), commentsCount, nil
+}
+
+func (s *ArticleStore) checkArticleFavorited(ctx context.Context, articleID, userID int64) (bool, error) {
 	var favorited bool
 	query := `SELECT EXISTS(SELECT 1 FROM favorites WHERE article_id = $1 AND user_id = $2)`
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, articleID, userID).Scan(&favorited)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, articleID, userID).Scan(&favorited)
+	})
 	if err != nil {
 		return false, err
 	}
@@ -237,18 +587,33 @@ func (s *ArticleStore) checkArticleFavorited(articleID, userID int64) (bool, err
 
 // FavoriteBySlug favorites an article for the given user and returns the updated article.
 // Uses a single CTE query for optimal performance - no separate transaction needed.
-func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+// If self-favoriting is forbidden, it returns ErrSelfFavorite when the user is the author.
+// If expectedVersion is not 0 and the article's version differs, nothing is changed and
+// ErrEditConflict is returned.
+func (s *ArticleStore) FavoriteBySlug(ctx context.Context, slug string, userID int64, expectedVersion int) (*Article, error) {
+	return s.favorite(ctx, "slug", slug, userID, expectedVersion)
+}
+
+// FavoriteByID is FavoriteBySlug for the article with the given ID.
+func (s *ArticleStore) FavoriteByID(ctx context.Context, id, userID int64, expectedVersion int) (*Article, error) {
+	return s.favorite(ctx, "id", id, userID, expectedVersion)
+}
+
+// favorite favorites the article whose column, slug or id, has the given value.
+func (s *ArticleStore) favorite(ctx context.Context, column string, value any, userID int64, expectedVersion int) (*Article, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	// Single optimized query using CTE to:
-	// 1. Look up article ID from slug
+	// 1. Look up article ID from slug or ID (skipping the user's own article if self-favoriting is forbidden,
+	//    or a stale version when one is expected)
 	// 2. Insert favorite (idempotent with ON CONFLICT DO NOTHING)
-	// 3. Update favorites_count only if a new favorite was inserted
+	// 3. Update favorites_count only if a new favorite was inserted (unless the count is computed)
 	// 4. Return complete article with author, favorited, and following status
-	query := `
+	query := fmt.Sprintf(`
 		WITH article_lookup AS (
-			SELECT id FROM articles WHERE slug = $1
+			SELECT id FROM articles
+			WHERE %[1]s = $1 AND NOT ($3 AND author_id = $2) AND ($4 = 0 OR version = $4)
 		),
 		favorite_insert AS (
 			INSERT INTO favorites (user_id, article_id)
@@ -260,7 +625,7 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 			UPDATE articles a
 			SET favorites_count = favorites_count + 1
 			FROM favorite_insert fi
-			WHERE a.id = fi.article_id
+			WHERE a.id = fi.article_id AND NOT $5
 			RETURNING a.id, a.slug, a.title, a.description, a.body, a.tag_list,
 			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id
 		)
@@ -269,28 +634,34 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 		       COALESCE(uc.title, a.title),
 		       COALESCE(uc.description, a.description),
 		       COALESCE(uc.body, a.body),
+		       a.body_type,
+		       a.cover_image,
 		       COALESCE(uc.tag_list, a.tag_list),
 		       COALESCE(uc.created_at, a.created_at),
 		       COALESCE(uc.updated_at, a.updated_at),
-		       COALESCE(uc.favorites_count, a.favorites_count),
+		       -- The main query doesn't see the insert above, so add it to a computed count
+		       CASE WHEN $5
+		            THEN (SELECT COUNT(*) FROM favorites fc WHERE fc.article_id = a.id) + (SELECT COUNT(*) FROM favorite_insert)
+		            ELSE COALESCE(uc.favorites_count, a.favorites_count)
+		       END,
 		       COALESCE(uc.version, a.version),
 		       COALESCE(uc.author_id, a.author_id),
 		       u.username, u.bio, u.image,
 		       true AS favorited,
 		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following
 		FROM articles a
-		LEFT JOIN update_count uc ON a.slug = $1
+		LEFT JOIN update_count uc ON a.%[1]s = $1
 		JOIN users u ON a.author_id = u.id
-		WHERE a.slug = $1
-	`
+		WHERE a.%[1]s = $1
+	`, column)
 
 	var article Article
 	var author Profile
 	var following bool
 
-	err := s.db.QueryRow(ctx, query, slug, userID).Scan(
+	err := s.db.QueryRow(ctx, query, value, userID, s.forbidSelfFavorite, expectedVersion, s.computedFavoritesCount).Scan(
 		&article.ID, &article.Slug, &article.Title, &article.Description,
-		&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
+		&article.Body, &article.BodyType, &article.CoverImage, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
 		&article.FavoritesCount, &article.Version, &article.AuthorID,
 		&author.Username, &author.Bio, &author.Image,
 		&article.Favorited,
@@ -304,26 +675,47 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 		return nil, err
 	}
 
+	// The lookup skipped the article, so no favorite was recorded
+	if expectedVersion != 0 && article.Version != expectedVersion {
+		return nil, ErrEditConflict
+	}
+	if s.forbidSelfFavorite && article.AuthorID == userID {
+		return nil, ErrSelfFavorite
+	}
+
 	author.Following = following
 	article.Author = author
+	article.TagList = emptyIfNil(article.TagList)
 
 	return &article, nil
 }
 
 // UnfavoriteBySlug unfavorites an article for the given user and returns the updated article.
 // Uses a single CTE query for optimal performance - no separate transaction needed.
-func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+// If expectedVersion is not 0 and the article's version differs, nothing is changed and
+// ErrEditConflict is returned.
+func (s *ArticleStore) UnfavoriteBySlug(ctx context.Context, slug string, userID int64, expectedVersion int) (*Article, error) {
+	return s.unfavorite(ctx, "slug", slug, userID, expectedVersion)
+}
+
+// UnfavoriteByID is UnfavoriteBySlug for the article with the given ID.
+func (s *ArticleStore) UnfavoriteByID(ctx context.Context, id, userID int64, expectedVersion int) (*Article, error) {
+	return s.unfavorite(ctx, "id", id, userID, expectedVersion)
+}
+
+// unfavorite unfavorites the article whose column, slug or id, has the given value.
+func (s *ArticleStore) unfavorite(ctx context.Context, column string, value any, userID int64, expectedVersion int) (*Article, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	// Single optimized query using CTE to:
-	// 1. Look up article ID from slug
+	// 1. Look up article ID from slug or ID (skipping a stale version when one is expected)
 	// 2. Delete favorite record
-	// 3. Update favorites_count only if a favorite was actually deleted
+	// 3. Update favorites_count only if a favorite was actually deleted (unless the count is computed)
 	// 4. Return complete article with author, favorited, and following status
-	query := `
+	query := fmt.Sprintf(`
 		WITH article_lookup AS (
-			SELECT id FROM articles WHERE slug = $1
+			SELECT id FROM articles WHERE %[1]s = $1 AND ($3 = 0 OR version = $3)
 		),
 		favorite_delete AS (
 			DELETE FROM favorites
@@ -335,7 +727,7 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 			UPDATE articles a
 			SET favorites_count = GREATEST(favorites_count - 1, 0)
 			FROM favorite_delete fd
-			WHERE a.id = fd.article_id
+			WHERE a.id = fd.article_id AND NOT $4
 			RETURNING a.id, a.slug, a.title, a.description, a.body, a.tag_list,
 			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id
 		)
@@ -344,28 +736,34 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 		       COALESCE(uc.title, a.title),
 		       COALESCE(uc.description, a.description),
 		       COALESCE(uc.body, a.body),
+		       a.body_type,
+		       a.cover_image,
 		       COALESCE(uc.tag_list, a.tag_list),
 		       COALESCE(uc.created_at, a.created_at),
 		       COALESCE(uc.updated_at, a.updated_at),
-		       COALESCE(uc.favorites_count, a.favorites_count),
+		       -- The main query doesn't see the delete above, so subtract it from a computed count
+		       CASE WHEN $4
+		            THEN (SELECT COUNT(*) FROM favorites fc WHERE fc.article_id = a.id) - (SELECT COUNT(*) FROM favorite_delete)
+		            ELSE COALESCE(uc.favorites_count, a.favorites_count)
+		       END,
 		       COALESCE(uc.version, a.version),
 		       COALESCE(uc.author_id, a.author_id),
 		       u.username, u.bio, u.image,
 		       false AS favorited,
 		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following
 		FROM articles a
-		LEFT JOIN update_count uc ON a.slug = $1
+		LEFT JOIN update_count uc ON a.%[1]s = $1
 		JOIN users u ON a.author_id = u.id
-		WHERE a.slug = $1
-	`
+		WHERE a.%[1]s = $1
+	`, column)
 
 	var article Article
 	var author Profile
 	var following bool
 
-	err := s.db.QueryRow(ctx, query, slug, userID).Scan(
+	err := s.db.QueryRow(ctx, query, value, userID, expectedVersion, s.computedFavoritesCount).Scan(
 		&article.ID, &article.Slug, &article.Title, &article.Description,
-		&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
+		&article.Body, &article.BodyType, &article.CoverImage, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
 		&article.FavoritesCount, &article.Version, &article.AuthorID,
 		&author.Username, &author.Bio, &author.Image,
 		&article.Favorited,
@@ -379,38 +777,53 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 		return nil, err
 	}
 
+	// The lookup skipped the article, so no favorite was removed
+	if expectedVersion != 0 && article.Version != expectedVersion {
+		return nil, ErrEditConflict
+	}
+
 	author.Following = following
 	article.Author = author
+	article.TagList = emptyIfNil(article.TagList)
 
 	return &article, nil
 }
 
-func (s *ArticleStore) DeleteBySlug(slug string, authorID int64) error {
+func (s *ArticleStore) DeleteBySlug(ctx context.Context, slug string, authorID int64) error {
 	query := `
 		DELETE FROM articles
 		WHERE slug = $1 AND author_id = $2
+		RETURNING id
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	result, err := s.db.Exec(ctx, query, slug, authorID)
-	if err != nil {
-		return err
-	}
-
-	if result.RowsAffected() == 0 {
-		return ErrRecordNotFound
-	}
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var articleID int64
+		err := tx.QueryRow(ctx, query, slug, authorID).Scan(&articleID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
 
-	return nil
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    authorID,
+			Action:     AuditActionDelete,
+			TargetType: AuditTargetArticle,
+			TargetID:   articleID,
+			TargetSlug: slug,
+		})
+	})
 }
 
-func (s *ArticleStore) Update(article *Article) error {
+func (s *ArticleStore) Update(ctx context.Context, article *Article) error {
 	query := `
 		UPDATE articles
-		SET title = $1, description = $2, body = $3, slug = $4, updated_at = (NOW() AT TIME ZONE 'UTC'), version = version + 1
-		WHERE id = $5 AND version = $6
+		SET title = $1, description = $2, body = $3, body_type = $4, cover_image = $5, slug = $6, updated_at = (NOW() AT TIME ZONE 'UTC'), version = version + 1
+		WHERE id = $7 AND version = $8
 		RETURNING updated_at, version
 	`
 
@@ -418,24 +831,40 @@ func (s *ArticleStore) Update(article *Article) error {
 		article.Title,
 		article.Description,
 		article.Body,
+		article.BodyType,
+		article.CoverImage,
 		article.Slug,
 		article.ID,
 		article.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, args...).Scan(&article.UpdatedAt, &article.Version)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrEditConflict
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, args...).Scan(&article.UpdatedAt, &article.Version)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEditConflict
+			}
+			return err
 		}
+
+		// Only the author may update an article, so the author is the actor
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    article.AuthorID,
+			Action:     AuditActionUpdate,
+			TargetType: AuditTargetArticle,
+			TargetID:   article.ID,
+			TargetSlug: article.Slug,
+		})
+	})
+	if err != nil {
 		return err
 	}
 
 	if len(article.TagList) > 0 {
-		if err = s.InsertTags(article.TagList...); err != nil {
+		if _, err = s.InsertTags(ctx, article.TagList...); err != nil {
 			return err
 		}
 
@@ -444,30 +873,68 @@ func (s *ArticleStore) Update(article *Article) error {
 	return nil
 }
 
-func (s *ArticleStore) InsertTags(tags ...string) error {
-	query := `INSERT INTO tags (tag) SELECT UNNEST($1::text[]) ON CONFLICT (tag) DO NOTHING`
+// InsertTags adds the normalized tags to the tags table, ignoring tags that already exist.
+// It returns the tags that were newly inserted, sorted alphabetically.
+func (s *ArticleStore) InsertTags(ctx context.Context, tags ...string) ([]string, error) {
+	query := `
+		INSERT INTO tags (tag) SELECT DISTINCT UNNEST($1::text[]) ON CONFLICT (tag) DO NOTHING
+		RETURNING tag`
+
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = NormalizeTag(tag)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	_, err := s.db.Exec(ctx, query, tags)
+	rows, err := s.db.Query(ctx, query, normalized)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	// Conflicting tags aren't returned, so only the newly inserted ones are collected
+	var newTags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		newTags = append(newTags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(newTags)
+
+	return emptyIfNil(newTags), nil
 }
 
 // ArticleFilters holds filtering and pagination parameters for listing articles
 type ArticleFilters struct {
-	Tag       string // Filter articles by tag name (exact match)
-	Author    string // Filter articles by author username
-	Favorited string // Filter articles favorited by a specific username
-	Feed      bool   // If true, only return articles from users that the current user follows
-	Limit     int    // Maximum number of articles to return
-	Offset    int    // Number of articles to skip (for pagination)
+	Tags      []string // Filter articles having all of these tags (case-insensitive match)
+	Author    string   // Filter articles by author username
+	Favorited string   // Filter articles favorited by a specific username
+	Search    string   // Filter articles whose title, description or body contain the text (case-insensitive)
+	Feed      bool     // If true, only return articles from users that the current user follows
+	Algorithm string   // Feed ordering, one of FeedAlgorithms; empty means FeedAlgorithmChronological
+	Sort      string   // Ordering, one of ArticleSorts; empty means ArticleSortRecent
+	Fields    []string // Article JSON fields to select and return; empty means all of ArticleListFields
+	Limit     int      // Maximum number of articles to return
+	Offset    int      // Number of articles to skip (for pagination)
 }
 
+// MaxSearchLength is the maximum length, in characters, of ArticleFilters.Search.
+const MaxSearchLength = 200
+
+// MaxFilterTags is the maximum number of tags in ArticleFilters.Tags.
+const MaxFilterTags = 10
+
+// likeEscaper escapes the LIKE wildcards, and the escape character itself, so that search
+// text is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // alphanumericRX validates strings containing only alphanumeric characters, underscores, and hyphens.
 // This is used for validating usernames, tags, and other user-provided identifiers.
 var alphanumericRX = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
@@ -476,11 +943,11 @@ var alphanumericRX = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 // Note: Pagination parameters (Limit and Offset) are validated and normalized
 // by the readPagination helper before reaching this method.
 func (f ArticleFilters) Validate(v *validator.Validator) {
-	// Validate tag length and characters if provided
-	if f.Tag != "" {
-		v.Check(len(f.Tag) <= 50, "Tag must not be more than 50 characters")
-		v.Check(len(f.Tag) >= 1, "Tag must not be empty")
-		v.Check(alphanumericRX.MatchString(f.Tag), "Tag must contain only alphanumeric characters, hyphens, and underscores")
+	// Validate the number of tags, and each tag's length and characters
+	v.Check(len(f.Tags) <= MaxFilterTags, fmt.Sprintf("Tag must not be given more than %d times", MaxFilterTags))
+	for _, tag := range f.Tags {
+		v.Check(len(tag) <= 50, "Tag must not be more than 50 characters")
+		v.Check(alphanumericRX.MatchString(tag), "Tag must contain only alphanumeric characters, hyphens, and underscores")
 	}
 
 	// Validate author username length and characters if provided
@@ -496,29 +963,173 @@ func (f ArticleFilters) Validate(v *validator.Validator) {
 		v.Check(len(f.Favorited) >= 1, "Favorited username must not be empty")
 		v.Check(alphanumericRX.MatchString(f.Favorited), "Favorited username must contain only alphanumeric characters, hyphens, and underscores")
 	}
+
+	// Validate the search text length if provided
+	if f.Search != "" {
+		v.Check(utf8.RuneCountInString(f.Search) <= MaxSearchLength, fmt.Sprintf("Search must not be more than %d characters", MaxSearchLength))
+	}
+
+	// Validate the feed ordering if provided
+	if f.Algorithm != "" {
+		v.Check(validator.PermittedValue(f.Algorithm, FeedAlgorithms...), "Algorithm must be one of chronological, engagement")
+	}
+
+	// Validate the ordering if provided
+	if f.Sort != "" {
+		v.Check(validator.PermittedValue(f.Sort, ArticleSorts...), fmt.Sprintf("Sort must be one of %s", strings.Join(ArticleSorts, ", ")))
+	}
+
+	// Validate requested fields against the whitelist of list fields
+	for _, field := range f.Fields {
+		v.Check(validator.PermittedValue(field, ArticleListFields...), fmt.Sprintf("Fields contains unknown field %q", field))
+	}
+}
+
+// Feed orderings supported by ArticleFilters.Algorithm.
+const (
+	FeedAlgorithmChronological = "chronological"
+	FeedAlgorithmEngagement    = "engagement"
+)
+
+// FeedAlgorithms lists the supported feed orderings.
+var FeedAlgorithms = []string{FeedAlgorithmChronological, FeedAlgorithmEngagement}
+
+// Article orderings supported by ArticleFilters.Sort.
+const (
+	ArticleSortRecent   = "recent"   // Most recent first
+	ArticleSortOldest   = "oldest"   // Least recent first
+	ArticleSortTrending = "trending" // Highest engagement score first, then most recent
+)
+
+// ArticleSorts lists the supported article orderings.
+var ArticleSorts = []string{ArticleSortRecent, ArticleSortOldest, ArticleSortTrending}
+
+// engagementScore returns the ordering that ranks articles by favorites, given by the
+// favoritesCount expression, decayed by age in hours, so that a well-liked recent article
+// outranks both a newer unnoticed one and an old popular one.
+func engagementScore(favoritesCount string) string {
+	return `(` + favoritesCount + ` + 1) /
+	POWER(EXTRACT(EPOCH FROM ((NOW() AT TIME ZONE 'UTC') - a.created_at)) / 3600 + 2, 1.5) DESC`
+}
+
+// ArticleListFields are the article JSON fields that can be requested through
+// ArticleFilters.Fields, in the order they are selected.
+var ArticleListFields = []string{
+	"slug", "title", "description", "bodyType", "coverImage", "tagList", "createdAt", "updatedAt",
+	"favoritesCount", "favorited", "author",
+}
+
+// articleColumn is a single column selected by List together with the scan destination
+// it populates on the row being read.
+type articleColumn struct {
+	expr string
+	dest func(r *articleRow) any
+}
+
+// articleRow holds the values scanned from a single List result row.
+type articleRow struct {
+	article    Article
+	author     Profile
+	following  bool
+	totalCount int
+}
+
+// articleFieldColumns maps each entry in ArticleListFields to the columns List needs to
+// select for it. The slug is always selected, so it has no columns of its own.
+var articleFieldColumns = map[string][]articleColumn{
+	"slug":        nil,
+	"title":       {{"a.title", func(r *articleRow) any { return &r.article.Title }}},
+	"description": {{"a.description", func(r *articleRow) any { return &r.article.Description }}},
+	"bodyType":    {{"a.body_type", func(r *articleRow) any { return &r.article.BodyType }}},
+	"coverImage":  {{"a.cover_image", func(r *articleRow) any { return &r.article.CoverImage }}},
+	"tagList":     {{"a.tag_list", func(r *articleRow) any { return &r.article.TagList }}},
+	"createdAt":   {{"a.created_at", func(r *articleRow) any { return &r.article.CreatedAt }}},
+	"updatedAt":   {{"a.updated_at", func(r *articleRow) any { return &r.article.UpdatedAt }}},
+	"favoritesCount": {
+		{"a.favorites_count", func(r *articleRow) any { return &r.article.FavoritesCount }},
+	},
+	"favorited": {
+		{"COALESCE(fav.user_id IS NOT NULL, false) AS favorited", func(r *articleRow) any { return &r.article.Favorited }},
+	},
+	"author": {
+		{"u.username", func(r *articleRow) any { return &r.author.Username }},
+		{"u.bio", func(r *articleRow) any { return &r.author.Bio }},
+		{"u.image", func(r *articleRow) any { return &r.author.Image }},
+		{"COALESCE(fol.follower_id IS NOT NULL, false) AS following", func(r *articleRow) any { return &r.following }},
+	},
+}
+
+// articleBaseColumns are selected by List regardless of the requested fields.
+var articleBaseColumns = []articleColumn{
+	{"a.id", func(r *articleRow) any { return &r.article.ID }},
+	{"a.slug", func(r *articleRow) any { return &r.article.Slug }},
+	{"a.author_id", func(r *articleRow) any { return &r.article.AuthorID }},
+	{"a.version", func(r *articleRow) any { return &r.article.Version }},
+	{"COUNT(*) OVER() AS total_count", func(r *articleRow) any { return &r.totalCount }},
+}
+
+// Project returns a map holding only the given JSON fields of the article, suitable for
+// marshaling a sparse fieldset. Localized timestamps set by Localize accompany the
+// timestamps they belong to.
+func (a *Article) Project(fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "slug":
+			projected[field] = a.Slug
+		case "title":
+			projected[field] = a.Title
+		case "description":
+			projected[field] = a.Description
+		case "bodyType":
+			projected[field] = a.BodyType
+		case "coverImage":
+			projected[field] = a.CoverImage
+		case "tagList":
+			projected[field] = a.TagList
+		case "createdAt":
+			projected[field] = a.CreatedAt
+			if a.CreatedAtLocal != nil {
+				projected["createdAtLocal"] = a.CreatedAtLocal
+			}
+		case "updatedAt":
+			projected[field] = a.UpdatedAt
+			if a.UpdatedAtLocal != nil {
+				projected["updatedAtLocal"] = a.UpdatedAtLocal
+			}
+		case "favoritesCount":
+			projected[field] = a.FavoritesCount
+		case "favorited":
+			projected[field] = a.Favorited
+		case "author":
+			projected[field] = a.Author
+		}
+	}
+	return projected
 }
 
 // List retrieves articles with optional filtering and pagination.
-// Returns articles ordered by most recent first (created_at DESC).
+// Returns articles ordered by filters.Sort, most recent first (created_at DESC) by default.
 // Uses JOINs to efficiently fetch favorited and following status in a single query.
-func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Article, int, error) {
+// When filters.Fields is set, only the columns backing those fields are selected.
+func (s *ArticleStore) List(ctx context.Context, filters ArticleFilters, currentUser *User) ([]Article, int, error) {
 	// Use -1 for anonymous users (will never match real user IDs, so JOINs return NULL/false)
 	userID := int64(-1)
 	if currentUser != nil && !currentUser.IsAnonymous() {
 		userID = currentUser.ID
 	}
 
-	// Build base query using Squirrel - always include favorited and following columns
+	fields := filters.Fields
+	if len(fields) == 0 {
+		fields = ArticleListFields
+	}
+
+	columns, exprs := s.articleColumnsFor(fields)
+
+	// Build base query using Squirrel - always include favorited and following joins
 	// Note: body is excluded from list results for performance
 	// Use COUNT(*) OVER() window function to get total count in a single query
-	qb := sq.Select(
-		"a.id", "a.slug", "a.title", "a.description", "a.tag_list",
-		"a.created_at", "a.updated_at", "a.author_id", "a.version", "a.favorites_count",
-		"u.username", "u.bio", "u.image",
-		"COALESCE(fav.user_id IS NOT NULL, false) AS favorited",
-		"COALESCE(fol.follower_id IS NOT NULL, false) AS following",
-		"COUNT(*) OVER() AS total_count",
-	).
+	qb := sq.Select(exprs...).
 		From("articles a").
 		Join("users u ON a.author_id = u.id").
 		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
@@ -536,23 +1147,46 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 	}
 
 	// Add WHERE conditions based on filters
-	if filters.Tag != "" {
-		qb = qb.Where("? = ANY(a.tag_list)", filters.Tag)
+	if len(filters.Tags) > 0 {
+		// Tags are stored lowercased, so normalizing the filter makes it case-insensitive.
+		// Containment requires every tag to be present.
+		tags := make([]string, len(filters.Tags))
+		for i, tag := range filters.Tags {
+			tags[i] = NormalizeTag(tag)
+		}
+		qb = qb.Where("a.tag_list @> ?", tags)
 	}
 	if filters.Author != "" {
 		qb = qb.Where("u.username = ?", filters.Author)
 	}
 	if filters.Favorited != "" {
+		// Private favorites are only listed for their owner, so other users get no results
 		qb = qb.Where(sq.Expr(`EXISTS (
 			SELECT 1 FROM favorites fav_filter
 			JOIN users fu ON fav_filter.user_id = fu.id
 			WHERE fav_filter.article_id = a.id AND fu.username = ?
-		)`, filters.Favorited))
+			  AND (fu.favorites_public OR fu.id = ?)
+		)`, filters.Favorited, userID))
+	}
+	if filters.Search != "" {
+		// A substring match, so partial words match too; the total count reflects it as well
+		pattern := "%" + likeEscaper.Replace(filters.Search) + "%"
+		qb = qb.Where("(a.title ILIKE ? OR a.description ILIKE ? OR a.body ILIKE ?)", pattern, pattern, pattern)
+	}
+
+	// Rank by engagement when requested, falling back to recency for ties
+	if filters.Sort == ArticleSortTrending || (filters.Feed && filters.Algorithm == FeedAlgorithmEngagement) {
+		qb = qb.OrderBy(engagementScore(s.favoritesCountExpr()))
+	}
+
+	createdAtOrder := "a.created_at DESC"
+	if filters.Sort == ArticleSortOldest {
+		createdAtOrder = "a.created_at ASC"
 	}
 
 	// Add ordering and pagination
 	query, args, err := qb.
-		OrderBy("a.created_at DESC").
+		OrderBy(createdAtOrder).
 		Limit(uint64(filters.Limit)).
 		Offset(uint64(filters.Offset)).
 		ToSql()
@@ -561,66 +1195,247 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 		return nil, 0, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
+	return s.queryArticles(ctx, query, args, columns, currentUser)
+}
 
-	// Execute query
-	rows, err := s.db.Query(ctx, query, args...)
+// Trending returns up to limit articles ranked by how often they were favorited. When
+// days is positive, only favorites made within that many days are counted and articles
+// without any recent favorites are left out; otherwise articles are ranked by their total
+// favorites count. Ties are broken by most recent first.
+func (s *ArticleStore) Trending(ctx context.Context, days, limit int, currentUser *User) ([]Article, error) {
+	userID := int64(-1)
+	if currentUser != nil && !currentUser.IsAnonymous() {
+		userID = currentUser.ID
+	}
+
+	columns, exprs := s.articleColumnsFor(ArticleListFields)
+
+	qb := sq.Select(exprs...).
+		From("articles a").
+		Join("users u ON a.author_id = u.id").
+		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
+		LeftJoin("follows fol ON a.author_id = fol.followed_id AND fol.follower_id = ?", userID).
+		PlaceholderFormat(sq.Dollar)
+
+	if days > 0 {
+		// Aggregate the recent favorites per article and rank by that count
+		qb = qb.Join(`(
+			SELECT article_id, COUNT(*) AS recent_count
+			FROM favorites
+			WHERE created_at >= (NOW() AT TIME ZONE 'UTC') - make_interval(days => ?)
+			GROUP BY article_id
+		) recent ON recent.article_id = a.id`, days).
+			OrderBy("recent.recent_count DESC")
+	} else {
+		qb = qb.Where(s.favoritesCountExpr() + " > 0").
+			OrderBy(s.favoritesCountExpr() + " DESC")
+	}
+
+	query, args, err := qb.
+		OrderBy("a.created_at DESC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	articles, _, err := s.queryArticles(ctx, query, args, columns, currentUser)
+	return articles, err
+}
+
+// CommentedBy returns the distinct articles the author has commented on, ordered by their
+// most recent comment on each article first, together with the total number of such
+// articles. Like List, the article body is not included.
+func (s *ArticleStore) CommentedBy(ctx context.Context, authorID int64, limit, offset int, currentUser *User) ([]Article, int, error) {
+	userID := int64(-1)
+	if currentUser != nil && !currentUser.IsAnonymous() {
+		userID = currentUser.ID
+	}
+
+	columns, exprs := s.articleColumnsFor(ArticleListFields)
+
+	// Collapse the author's comments to one row per article before joining, so each
+	// article appears once no matter how many times it was commented on
+	query, args, err := sq.Select(exprs...).
+		From("articles a").
+		Join(`(
+			SELECT article_id, MAX(created_at) AS last_commented_at
+			FROM comments
+			WHERE author_id = ?
+			GROUP BY article_id
+		) c ON c.article_id = a.id`, authorID).
+		Join("users u ON a.author_id = u.id").
+		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
+		LeftJoin("follows fol ON a.author_id = fol.followed_id AND fol.follower_id = ?", userID).
+		OrderBy("c.last_commented_at DESC", "a.id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
 
-	var articles []Article
-	var totalCount int
+	return s.queryArticles(ctx, query, args, columns, currentUser)
+}
 
-	for rows.Next() {
-		var article Article
-		var author Profile
-		var favorited, following bool
+// Related returns up to limit articles sharing tags with the article with the given ID,
+// ordered by the number of shared tags, then most recent first. The article itself is
+// never included, and neither are other articles by its author when excludeSameAuthor is
+// set. Like List, the article body is not included.
+func (s *ArticleStore) Related(ctx context.Context, articleID int64, limit int, excludeSameAuthor bool, currentUser *User) ([]Article, error) {
+	userID := int64(-1)
+	if currentUser != nil && !currentUser.IsAnonymous() {
+		userID = currentUser.ID
+	}
 
-		err := rows.Scan(
-			&article.ID,
-			&article.Slug,
-			&article.Title,
-			&article.Description,
-			&article.TagList,
-			&article.CreatedAt,
-			&article.UpdatedAt,
-			&article.AuthorID,
-			&article.Version,
-			&article.FavoritesCount,
-			&author.Username,
-			&author.Bio,
-			&author.Image,
-			&favorited,
-			&following,
-			&totalCount,
-		)
-		if err != nil {
-			return nil, 0, err
+	columns, exprs := s.articleColumnsFor(ArticleListFields)
+
+	qb := sq.Select(exprs...).
+		From("articles a").
+		Join("articles src ON src.id = ?", articleID).
+		Join("users u ON a.author_id = u.id").
+		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
+		LeftJoin("follows fol ON a.author_id = fol.followed_id AND fol.follower_id = ?", userID).
+		Where("a.id <> src.id").
+		// && uses the GIN index on tag_list to find candidates sharing at least one tag
+		Where("a.tag_list && src.tag_list").
+		PlaceholderFormat(sq.Dollar)
+
+	if excludeSameAuthor {
+		qb = qb.Where("a.author_id <> src.author_id")
+	}
+
+	query, args, err := qb.
+		OrderBy(
+			"cardinality(ARRAY(SELECT unnest(a.tag_list) INTERSECT SELECT unnest(src.tag_list))) DESC",
+			"a.created_at DESC",
+		).
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	articles, _, err := s.queryArticles(ctx, query, args, columns, currentUser)
+	return articles, err
+}
+
+// GetBySlugs retrieves the articles with the given slugs in a single query, in the order
+// the slugs were given. Slugs that don't match an article are omitted, and duplicate slugs
+// are only returned once. Like List, the article body is not included.
+func (s *ArticleStore) GetBySlugs(ctx context.Context, slugs []string, currentUser *User) ([]Article, error) {
+	userID := int64(-1)
+	if currentUser != nil && !currentUser.IsAnonymous() {
+		userID = currentUser.ID
+	}
+
+	columns, exprs := s.articleColumnsFor(ArticleListFields)
+
+	query, args, err := sq.Select(exprs...).
+		From("articles a").
+		Join("users u ON a.author_id = u.id").
+		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
+		LeftJoin("follows fol ON a.author_id = fol.followed_id AND fol.follower_id = ?", userID).
+		Where("a.slug = ANY(?)", slugs).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	found, _, err := s.queryArticles(ctx, query, args, columns, currentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	bySlug := make(map[string]Article, len(found))
+	for _, article := range found {
+		bySlug[article.Slug] = article
+	}
+
+	articles := make([]Article, 0, len(found))
+	for _, slug := range slugs {
+		if article, ok := bySlug[slug]; ok {
+			articles = append(articles, article)
+			delete(bySlug, slug)
 		}
+	}
 
-		article.Favorited = favorited
-		// Don't set following to true if current user is the author
-		if currentUser != nil && article.AuthorID == currentUser.ID {
-			author.Following = false
-		} else {
-			author.Following = following
+	return articles, nil
+}
+
+// articleColumnsFor returns the columns, and their select expressions, needed to
+// populate the given article list fields.
+func (s *ArticleStore) articleColumnsFor(fields []string) ([]articleColumn, []string) {
+	columns := append([]articleColumn{}, articleBaseColumns...)
+	for _, field := range ArticleListFields {
+		if !slices.Contains(fields, field) {
+			continue
+		}
+		for _, column := range articleFieldColumns[field] {
+			if field == "favoritesCount" {
+				column.expr = s.favoritesCountExpr()
+			}
+			columns = append(columns, column)
 		}
+	}
 
-		article.Author = author
-		articles = append(articles, article)
+	exprs := make([]string, len(columns))
+	for i, column := range columns {
+		exprs[i] = column.expr
 	}
 
-	if err = rows.Err(); err != nil {
+	return columns, exprs
+}
+
+// queryArticles runs an article list query selecting the given columns and scans the
+// resulting rows. It also returns the total count selected by articleBaseColumns.
+func (s *ArticleStore) queryArticles(ctx context.Context, query string, args []any, columns []articleColumn, currentUser *User) ([]Article, int, error) {
+	var articles []Article
+	var totalCount int
+
+	// Execute query
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		articles, totalCount = nil, 0
+		for rows.Next() {
+			var row articleRow
+
+			dest := make([]any, len(columns))
+			for i, column := range columns {
+				dest[i] = column.dest(&row)
+			}
+
+			err := rows.Scan(dest...)
+			if err != nil {
+				return err
+			}
+
+			article, author := row.article, row.author
+			// Don't set following to true if current user is the author
+			if currentUser != nil && article.AuthorID == currentUser.ID {
+				author.Following = false
+			} else {
+				author.Following = row.following
+			}
+
+			article.Author = author
+			article.TagList = emptyIfNil(article.TagList)
+			totalCount = row.totalCount
+			articles = append(articles, article)
+		}
+		return rows.Err()
+	})
+	if err != nil {
 		return nil, 0, err
 	}
 
 	// If no articles found, return empty slice instead of nil to ensure JSON marshals to [] not null
-	if articles == nil {
-		articles = []Article{}
-	}
-
-	return articles, totalCount, nil
+	return emptyIfNil(articles), totalCount, nil
 }
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticleGenerateSlug(t *testing.T) {
+	slugRX := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*-[a-z0-9]{7}$`)
+
+	testCases := []struct {
+		name          string
+		title         string
+		maxBaseLength int
+		wantBase      string
+	}{
+		{"Short title", "Hello, World!", 20, "hello-world"},
+		{"No limit", strings.Repeat("word ", 50), 0, strings.TrimSuffix(strings.Repeat("word-", 50), "-")},
+		{"Cut mid-word", "Understanding concurrency in Go", 20, "understanding-concur"},
+		{"Cut at a hyphen", "Understanding concurrency in Go", 14, "understanding"},
+		{"Exactly the limit", "Understanding concurrency", 25, "understanding-concurrency"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			article := &Article{Title: tc.title}
+			article.GenerateSlug(tc.maxBaseLength)
+
+			assert.Regexp(t, slugRX, article.Slug)
+			assert.Equal(t, tc.wantBase, article.Slug[:len(article.Slug)-len("-xxxxxxx")])
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	testCases := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"Single word", "Golang", "golang"},
+		{"Spaces", "How to train your dragon", "how-to-train-your-dragon"},
+		{"Uppercase", "HELLO World", "hello-world"},
+		{"Punctuation", "Hello, World! What's up?", "hello-world-whats-up"},
+		{"Digits", "Top 10 Go tips for 2024", "top-10-go-tips-for-2024"},
+		{"Consecutive spaces", "too    many   spaces", "too-many-spaces"},
+		{"Consecutive hyphens", "already--hyphenated---title", "already-hyphenated-title"},
+		{"Punctuation between words", "before - after", "before-after"},
+		{"Leading and trailing spaces", "  padded title  ", "padded-title"},
+		{"Leading and trailing hyphens", "--dashed--", "dashed"},
+		{"Non-ASCII letters are dropped", "Café naïve", "caf-nave"},
+		{"Only punctuation", "!!!", ""},
+		{"Empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, slugify(tc.title))
+		})
+	}
+}
+
+func TestArticleDedupeTags(t *testing.T) {
+	testCases := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"No duplicates", []string{"go", "api", "db"}, []string{"go", "api", "db"}},
+		{"Keeps first occurrence order", []string{"go", "api", "go", "db", "api"}, []string{"go", "api", "db"}},
+		{"All duplicates", []string{"go", "go", "go"}, []string{"go"}},
+		{"Empty", []string{}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			article := Article{TagList: tc.tags}
+			article.DedupeTags()
+			assert.Equal(t, tc.want, article.TagList)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	testCases := []struct {
+		name      string
+		body      string
+		maxLength int
+		want      string
+	}{
+		{"Shorter than the limit", "Short body", 20, "Short body"},
+		{"Exactly the limit", "Exactly twenty chars", 20, "Exactly twenty chars"},
+		{"Cut at a word boundary", "The quick brown fox jumps over the lazy dog", 18, "The quick brown…"},
+		{"Limit falls on a space", "The quick brown fox", 16, "The quick brown…"},
+		{"Single overlong word", "Supercalifragilisticexpialidocious", 10, "Supercali…"},
+		{"Whitespace is collapsed", "  Line one\n\nLine   two\tend  ", 50, "Line one Line two end"},
+		{"Zero limit", "Any body", 0, ""},
+		{"Multi-byte characters", "Ünïcödé wörds everywhere", 14, "Ünïcödé wörds…"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Excerpt(tc.body, tc.maxLength)
+			assert.Equal(t, tc.want, got)
+			assert.LessOrEqual(t, utf8.RuneCountInString(got), tc.maxLength)
+		})
+	}
+}
+
+func TestArticleDefaultDescription(t *testing.T) {
+	body := "The quick brown fox jumps over the lazy dog"
+
+	article := &Article{Description: "Given", Body: body}
+	article.DefaultDescription(10)
+	assert.Equal(t, "Given", article.Description)
+
+	article = &Article{Description: "   ", Body: body}
+	article.DefaultDescription(10)
+	assert.Equal(t, "The quick…", article.Description)
+
+	article = &Article{Body: body}
+	article.DefaultDescription(0)
+	assert.Empty(t, article.Description)
+}
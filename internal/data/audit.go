@@ -0,0 +1,41 @@
+package data
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Audit actions recorded for content mutations.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Audit target types.
+const (
+	AuditTargetArticle = "article"
+	AuditTargetComment = "comment"
+)
+
+// AuditEntry is a single row of the append-only audit_log table. It is never exposed
+// through the API and exists for operators only.
+type AuditEntry struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   int64
+	TargetSlug string // Empty for targets without a slug, such as comments
+}
+
+// insertAudit writes an audit entry using tx, so that the entry is committed or rolled
+// back together with the mutation it describes.
+func insertAudit(ctx context.Context, tx pgx.Tx, entry AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, target_slug)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.Exec(ctx, query, entry.ActorID, entry.Action, entry.TargetType, entry.TargetID, entry.TargetSlug)
+	return err
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -27,9 +28,12 @@ func (uc *UserCache) Get(userID int64) (*User, bool) {
 		return nil, false
 	}
 
-	// Type assert and return a copy to prevent external modifications
+	// Type assert and return a copy to prevent external modifications.
+	// A failed assertion means the entry is corrupt, so evict it and treat it as a miss.
 	user, ok := val.(*User)
 	if !ok {
+		uc.c.Delete(key)
+		slog.Warn("evicted corrupt user cache entry", "key", key, "type", fmt.Sprintf("%T", val))
 		return nil, false
 	}
 
@@ -0,0 +1,43 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCache_SetGetDelete(t *testing.T) {
+	uc := NewUserCache(time.Minute, time.Minute)
+
+	uc.Set(1, &User{ID: 1, Username: "alice"})
+
+	user, found := uc.Get(1)
+	require.True(t, found)
+	assert.Equal(t, "alice", user.Username)
+
+	// Modifying the returned copy must not affect the cached value
+	user.Username = "mallory"
+	user, _ = uc.Get(1)
+	assert.Equal(t, "alice", user.Username)
+
+	uc.Delete(1)
+	_, found = uc.Get(1)
+	assert.False(t, found)
+}
+
+func TestUserCache_CorruptEntry(t *testing.T) {
+	uc := NewUserCache(time.Minute, time.Minute)
+
+	// Store a value of the wrong type directly in the underlying cache
+	uc.c.Set(uc.key(1), "not a user", time.Minute)
+
+	user, found := uc.Get(1)
+	assert.False(t, found)
+	assert.Nil(t, user)
+
+	// The corrupt entry is evicted from the underlying cache
+	_, found = uc.c.Get(uc.key(1))
+	assert.False(t, found)
+}
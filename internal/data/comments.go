@@ -2,89 +2,191 @@ package data
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/manas-solves/realworld-backend/internal/validator"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Comment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	ArticleID int64     `json:"-"`
-	AuthorID  int64     `json:"-"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Author    Profile   `json:"author"`
+	ID             int64      `json:"id"`
+	Body           string     `json:"body"`
+	BodyType       string     `json:"bodyType"`
+	BodyHTML       string     `json:"bodyHtml,omitempty"`
+	ArticleID      int64      `json:"-"`
+	AuthorID       int64      `json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	CreatedAtLocal *time.Time `json:"createdAtLocal,omitempty"` // Only set by Localize
+	UpdatedAtLocal *time.Time `json:"updatedAtLocal,omitempty"` // Only set by Localize
+	Author         Profile    `json:"author"`
 }
 
 func ValidateComment(v *validator.Validator, comment *Comment) {
 	v.Check(validator.NotEmptyOrWhitespace(comment.Body),
 		"Body must not be empty or whitespace only")
+	v.Check(validator.PermittedValue(comment.BodyType, BodyTypes...),
+		fmt.Sprintf("BodyType must be one of %s", strings.Join(BodyTypes, ", ")))
+}
+
+// Localize sets CreatedAtLocal and UpdatedAtLocal to the comment's timestamps in loc,
+// leaving the UTC timestamps as they are. It does nothing when loc is nil.
+func (c *Comment) Localize(loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	createdAt, updatedAt := c.CreatedAt.In(loc), c.UpdatedAt.In(loc)
+	c.CreatedAtLocal, c.UpdatedAtLocal = &createdAt, &updatedAt
 }
 
 type CommentStore struct {
 	db      *pgxpool.Pool
 	timeout time.Duration
+	retry   RetryPolicy
 }
 
 // InsertAndReturn inserts a comment and populates it with database-generated fields and author details.
 // Modifies the input comment object in place and uses currentUser from context instead of querying the database.
-func (s *CommentStore) InsertAndReturn(comment *Comment, currentUser *User) (*Comment, error) {
+// It also returns the article's number of comments, including the new one.
+// Returns ErrRecordNotFound if the article no longer exists.
+func (s *CommentStore) InsertAndReturn(ctx context.Context, comment *Comment, currentUser *User) (*Comment, int, error) {
 	query := `
-		INSERT INTO comments (body, article_id, author_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO comments (body, body_type, article_id, author_id)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at, updated_at
 	`
 
-	args := []any{comment.Body, comment.ArticleID, comment.AuthorID}
+	args := []any{comment.Body, comment.BodyType, comment.ArticleID, comment.AuthorID}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	// Scan only the fields we don't already have into the input object
-	err := s.db.QueryRow(ctx, query, args...).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+	var commentsCount int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		// Scan only the fields we don't already have into the input object
+		err := tx.QueryRow(ctx, query, args...).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		// Count within the transaction so that the new comment is included
+		err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM comments WHERE article_id = $1`, comment.ArticleID).Scan(&commentsCount)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    comment.AuthorID,
+			Action:     AuditActionCreate,
+			TargetType: AuditTargetComment,
+			TargetID:   comment.ID,
+		})
+	})
 	if err != nil {
-		return nil, err
+		// The article was deleted after the caller looked up its ID
+		if isPgError(err, pgForeignKeyViolation) {
+			return nil, 0, ErrRecordNotFound
+		}
+		return nil, 0, err
 	}
 
 	// Use author information from currentUser context instead of querying database
 	// Following is always false for newly created comments (user doesn't follow themselves)
 	comment.Author = currentUser.ToProfile(false)
 
-	return comment, nil
+	return comment, commentsCount, nil
 }
 
-// GetByArticleID retrieves all comments for an article by its article ID.
+// GetByArticleID retrieves a page of the comments for an article by its article ID.
 // Returns comments with author details, ordered by creation time (newest first).
 // Uses JOIN to efficiently fetch author information in a single query.
-func (s *CommentStore) GetByArticleID(articleID int64) ([]Comment, error) {
+// At most limit comments (0 means no limit) are returned after skipping offset of them,
+// together with the total number of comments on the article.
+func (s *CommentStore) GetByArticleID(ctx context.Context, articleID int64, limit, offset int) ([]Comment, int, error) {
 	query := `
-		SELECT c.id, c.body, c.article_id, c.author_id, c.created_at, c.updated_at,
-		       u.username, u.bio, u.image
+		SELECT c.id, c.body, c.body_type, c.article_id, c.author_id, c.created_at, c.updated_at,
+		       u.username, u.bio, u.image,
+		       COUNT(*) OVER()
 		FROM comments c
 		JOIN users u ON c.author_id = u.id
 		WHERE c.article_id = $1
-		ORDER BY c.created_at DESC
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $2 OFFSET $3
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
+	// NULL means no limit
+	var rowLimit *int
+	if limit > 0 {
+		rowLimit = &limit
+	}
+
+	var comments []Comment
+	var totalCount int
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, articleID, rowLimit, offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		comments = nil
+		for rows.Next() {
+			var comment Comment
+			var author Profile
 
-	rows, err := s.db.Query(ctx, query, articleID)
+			err := rows.Scan(
+				&comment.ID,
+				&comment.Body,
+				&comment.BodyType,
+				&comment.ArticleID,
+				&comment.AuthorID,
+				&comment.CreatedAt,
+				&comment.UpdatedAt,
+				&author.Username,
+				&author.Bio,
+				&author.Image,
+				&totalCount,
+			)
+			if err != nil {
+				return err
+			}
+
+			comment.Author = author
+			comments = append(comments, comment)
+		}
+		return rows.Err()
+	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer rows.Close()
 
-	var comments []Comment
-	for rows.Next() {
-		var comment Comment
-		var author Profile
+	// Return empty slice instead of nil if no comments found
+	return emptyIfNil(comments), totalCount, nil
+}
+
+// GetByID retrieves a single comment on an article, with author details. It returns
+// ErrRecordNotFound if the comment doesn't exist or belongs to a different article.
+func (s *CommentStore) GetByID(ctx context.Context, articleID, id int64) (*Comment, error) {
+	query := `
+		SELECT c.id, c.body, c.body_type, c.article_id, c.author_id, c.created_at, c.updated_at,
+		       u.username, u.bio, u.image
+		FROM comments c
+		JOIN users u ON c.author_id = u.id
+		WHERE c.id = $1 AND c.article_id = $2
+	`
+
+	var comment Comment
+	var author Profile
 
-		err := rows.Scan(
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, id, articleID).Scan(
 			&comment.ID,
 			&comment.Body,
+			&comment.BodyType,
 			&comment.ArticleID,
 			&comment.AuthorID,
 			&comment.CreatedAt,
@@ -93,29 +195,150 @@ func (s *CommentStore) GetByArticleID(articleID int64) ([]Comment, error) {
 			&author.Bio,
 			&author.Image,
 		)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	comment.Author = author
+	return &comment, nil
+}
+
+// Update updates the comment's body and body type and bumps its updated_at, which is set
+// on the comment. The update only applies if the comment was written by authorID; otherwise,
+// or if the comment no longer exists, it returns ErrRecordNotFound.
+func (s *CommentStore) Update(ctx context.Context, comment *Comment, authorID int64) error {
+	query := `
+		UPDATE comments
+		SET body = $1, body_type = $2, updated_at = (NOW() AT TIME ZONE 'UTC')
+		WHERE id = $3 AND article_id = $4 AND author_id = $5
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, comment.Body, comment.BodyType, comment.ID, comment.ArticleID, authorID).Scan(&comment.UpdatedAt)
 		if err != nil {
-			return nil, err
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRecordNotFound
+			}
+			return err
 		}
 
-		comment.Author = author
-		comments = append(comments, comment)
-	}
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    authorID,
+			Action:     AuditActionUpdate,
+			TargetType: AuditTargetComment,
+			TargetID:   comment.ID,
+		})
+	})
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+// DeleteByID deletes the comment with the given ID on the article, only if it was written
+// by authorID. It returns ErrRecordNotFound if no such comment exists, so that comments
+// owned by other users are indistinguishable from missing ones.
+func (s *CommentStore) DeleteByID(ctx context.Context, articleID, commentID, authorID int64) error {
+	query := `
+		DELETE FROM comments
+		WHERE id = $1 AND article_id = $2 AND author_id = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, commentID, articleID, authorID)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrRecordNotFound
+		}
+
+		return insertAudit(ctx, tx, AuditEntry{
+			ActorID:    authorID,
+			Action:     AuditActionDelete,
+			TargetType: AuditTargetComment,
+			TargetID:   commentID,
+		})
+	})
+}
+
+// GetRecentBySlugs retrieves up to perArticle of the most recent comments on each of the
+// articles with the given slugs in a single query, grouped by article slug and ordered
+// newest first. Author following status is set relative to currentUserID (0 for anonymous
+// users). Unknown slugs and articles without comments are left out of the result.
+func (s *CommentStore) GetRecentBySlugs(ctx context.Context, slugs []string, perArticle int, currentUserID int64) (map[string][]Comment, error) {
+	if len(slugs) == 0 {
+		return map[string][]Comment{}, nil
 	}
 
-	// Return empty slice instead of nil if no comments found
-	if comments == nil {
-		comments = []Comment{}
+	query := `
+		SELECT a.slug, c.id, c.body, c.body_type, c.article_id, c.author_id, c.created_at, c.updated_at,
+		       u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM follows f WHERE f.follower_id = $3 AND f.followed_id = u.id)
+		FROM articles a
+		CROSS JOIN LATERAL (
+			SELECT * FROM comments
+			WHERE article_id = a.id
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		) c
+		JOIN users u ON c.author_id = u.id
+		WHERE a.slug = ANY($1)
+		ORDER BY a.slug, c.created_at DESC, c.id DESC
+	`
+
+	var grouped map[string][]Comment
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, slugs, perArticle, currentUserID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		grouped = make(map[string][]Comment)
+		for rows.Next() {
+			var slug string
+			var comment Comment
+
+			err := rows.Scan(
+				&slug,
+				&comment.ID,
+				&comment.Body,
+				&comment.BodyType,
+				&comment.ArticleID,
+				&comment.AuthorID,
+				&comment.CreatedAt,
+				&comment.UpdatedAt,
+				&comment.Author.Username,
+				&comment.Author.Bio,
+				&comment.Author.Image,
+				&comment.Author.Following,
+			)
+			if err != nil {
+				return err
+			}
+
+			grouped[slug] = append(grouped[slug], comment)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return comments, nil
+	return grouped, nil
 }
 
 // SetFollowingStatus efficiently checks and sets the following status for all comment authors.
 // Uses a single query with IN clause to check all authors at once.
-func (s *CommentStore) SetFollowingStatus(comments []Comment, currentUserID int64) error {
+func (s *CommentStore) SetFollowingStatus(ctx context.Context, comments []Comment, currentUserID int64) error {
 	if len(comments) == 0 || currentUserID == 0 {
 		return nil
 	}
@@ -139,26 +362,26 @@ func (s *CommentStore) SetFollowingStatus(comments []Comment, currentUserID int6
 		WHERE followed_id = ANY($1) AND follower_id = $2
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	rows, err := s.db.Query(ctx, query, authorIDs, currentUserID)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	// Build a set of author IDs that the current user is following
-	followingSet := make(map[int64]bool)
-	for rows.Next() {
-		var authorID int64
-		if err := rows.Scan(&authorID); err != nil {
+	var followingSet map[int64]bool
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, authorIDs, currentUserID)
+		if err != nil {
 			return err
 		}
-		followingSet[authorID] = true
-	}
+		defer rows.Close()
 
-	if err = rows.Err(); err != nil {
+		// Build a set of author IDs that the current user is following
+		followingSet = make(map[int64]bool)
+		for rows.Next() {
+			var authorID int64
+			if err := rows.Scan(&authorID); err != nil {
+				return err
+			}
+			followingSet[authorID] = true
+		}
+		return rows.Err()
+	})
+	if err != nil {
 		return err
 	}
 
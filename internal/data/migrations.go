@@ -0,0 +1,45 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationVersionTimeout bounds the migration version lookup, which backs the health
+// check and so must answer quickly even when the database is struggling.
+const migrationVersionTimeout = time.Second
+
+// MigrationStatus is the state of the schema migrations applied to the database.
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"` // A migration failed part way and needs manual attention
+}
+
+type MigrationStore struct {
+	db *pgxpool.Pool
+}
+
+// Status returns the version of the most recently applied migration, as recorded in the
+// schema_migrations table maintained by golang-migrate. It returns ErrRecordNotFound if
+// no migration has been applied.
+func (s *MigrationStore) Status(ctx context.Context) (*MigrationStatus, error) {
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	ctx, cancel := context.WithTimeout(ctx, migrationVersionTimeout)
+	defer cancel()
+
+	var status MigrationStatus
+	err := s.db.QueryRow(ctx, query).Scan(&status.Version, &status.Dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms for new password hashes. Hashes of either algorithm can
+// always be verified, so the algorithm can be changed without resetting passwords.
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// PasswordHashAlgorithms lists the permitted password hashing algorithms.
+var PasswordHashAlgorithms = []string{PasswordHashBcrypt, PasswordHashArgon2id}
+
+// DefaultBcryptCost is the default cost of new bcrypt hashes.
+const DefaultBcryptCost = 12
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id.
+var DefaultArgon2Params = Argon2Params{Memory: 19 * 1024, Iterations: 2, Parallelism: 1}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	// argon2Prefix starts every argon2id hash, telling them apart from bcrypt hashes.
+	argon2Prefix = "$argon2id$"
+)
+
+// ErrInvalidPasswordHash is returned when a stored password hash can't be parsed.
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHashing configures how new password hashes are created.
+type PasswordHashing struct {
+	Algorithm  string // One of PasswordHashAlgorithms
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// passwordHashing is used by password.Set. It defaults to bcrypt and may be replaced once
+// at startup via SetPasswordHashing.
+var passwordHashing = DefaultPasswordHashing
+
+// DefaultPasswordHashing hashes new passwords with bcrypt at the default cost.
+var DefaultPasswordHashing = PasswordHashing{
+	Algorithm:  PasswordHashBcrypt,
+	BcryptCost: DefaultBcryptCost,
+	Argon2:     DefaultArgon2Params,
+}
+
+// SetPasswordHashing replaces the settings used for new password hashes. It is not safe
+// for concurrent use and should only be called during application startup.
+func SetPasswordHashing(h PasswordHashing) {
+	passwordHashing = h
+}
+
+type password struct {
+	plaintext *string
+	hash      []byte
+}
+
+// Set hashes the plaintext password with the configured algorithm.
+func (p *password) Set(plaintextPassword string) error {
+	var hash []byte
+	var err error
+	if passwordHashing.Algorithm == PasswordHashArgon2id {
+		hash, err = argon2Hash(plaintextPassword, passwordHashing.Argon2)
+	} else {
+		hash, err = bcrypt.GenerateFromPassword([]byte(plaintextPassword), passwordHashing.BcryptCost)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.plaintext = &plaintextPassword
+	p.hash = hash
+
+	return nil
+}
+
+// Matches compares the plaintext password against the hash and returns true if they match.
+// The verifier is picked from the hash itself, whatever algorithm is configured.
+func (p *password) Matches(plaintextPassword string) (bool, error) {
+	if bytes.HasPrefix(p.hash, []byte(argon2Prefix)) {
+		return argon2Matches(p.hash, plaintextPassword)
+	}
+
+	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintextPassword))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// NeedsRehash reports whether the hash was created with a different algorithm or different
+// parameters than the ones configured, so that it should be replaced on the next login.
+func (p *password) NeedsRehash() bool {
+	if bytes.HasPrefix(p.hash, []byte(argon2Prefix)) {
+		params, _, _, err := decodeArgon2Hash(p.hash)
+		return err != nil || passwordHashing.Algorithm != PasswordHashArgon2id || params != passwordHashing.Argon2
+	}
+
+	// The cost is read from the hash prefix, as in $2a$12$
+	cost, err := bcrypt.Cost(p.hash)
+	return err != nil || passwordHashing.Algorithm != PasswordHashBcrypt || cost != passwordHashing.BcryptCost
+}
+
+// argon2Hash hashes the password with argon2id and a random salt, encoded in the PHC string
+// format: $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+func argon2Hash(plaintextPassword string, params Argon2Params) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(plaintextPassword), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	return []byte(encoded), nil
+}
+
+// argon2Matches compares the plaintext password against an argon2id hash, using the
+// parameters recorded in the hash.
+func argon2Matches(hash []byte, plaintextPassword string) (bool, error) {
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(plaintextPassword), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+// decodeArgon2Hash parses an argon2id hash created by argon2Hash.
+func decodeArgon2Hash(hash []byte) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	// The hash starts with "$", so the first part is empty
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	return params, salt, key, nil
+}
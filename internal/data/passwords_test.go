@@ -0,0 +1,112 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArgon2Params keep the argon2id hashes in the tests cheap.
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+// setTestPasswordHashing replaces the package-level password hashing settings for the test,
+// restoring the default afterwards. Tests calling it must not run in parallel.
+func setTestPasswordHashing(t *testing.T, h PasswordHashing) {
+	t.Helper()
+	previous := passwordHashing
+	SetPasswordHashing(h)
+	t.Cleanup(func() { SetPasswordHashing(previous) })
+}
+
+// TestPasswordHashing changes the package-level password hashing settings, so it isn't parallel.
+func TestPasswordHashing(t *testing.T) {
+	var bcryptPassword password
+	require.NoError(t, bcryptPassword.Set("pa55word1234"))
+	assert.True(t, strings.HasPrefix(string(bcryptPassword.hash), "$2a$"))
+
+	setTestPasswordHashing(t, PasswordHashing{Algorithm: PasswordHashArgon2id, Argon2: testArgon2Params})
+
+	t.Run("bcrypt hash still matches", func(t *testing.T) {
+		matches, err := bcryptPassword.Matches("pa55word1234")
+		require.NoError(t, err)
+		assert.True(t, matches)
+
+		matches, err = bcryptPassword.Matches("wrongpassword")
+		require.NoError(t, err)
+		assert.False(t, matches)
+
+		assert.True(t, bcryptPassword.NeedsRehash())
+	})
+
+	t.Run("new passwords hash with argon2id", func(t *testing.T) {
+		var p password
+		require.NoError(t, p.Set("pa55word1234"))
+		assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`, string(p.hash))
+		assert.False(t, p.NeedsRehash())
+
+		matches, err := p.Matches("pa55word1234")
+		require.NoError(t, err)
+		assert.True(t, matches)
+
+		matches, err = p.Matches("wrongpassword")
+		require.NoError(t, err)
+		assert.False(t, matches)
+
+		var other password
+		require.NoError(t, other.Set("pa55word1234"))
+		assert.NotEqual(t, p.hash, other.hash, "hashes should use a random salt")
+	})
+
+	t.Run("changed parameters need a rehash", func(t *testing.T) {
+		var p password
+		require.NoError(t, p.Set("pa55word1234"))
+
+		stronger := testArgon2Params
+		stronger.Iterations = 2
+		setTestPasswordHashing(t, PasswordHashing{Algorithm: PasswordHashArgon2id, Argon2: stronger})
+		assert.True(t, p.NeedsRehash())
+
+		// The hash records its own parameters, so it still matches
+		matches, err := p.Matches("pa55word1234")
+		require.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("malformed argon2id hash", func(t *testing.T) {
+		for _, hash := range []string{
+			"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+			"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$!!",
+		} {
+			p := password{hash: []byte(hash)}
+			_, err := p.Matches("pa55word1234")
+			assert.ErrorIs(t, err, ErrInvalidPasswordHash, hash)
+			assert.True(t, p.NeedsRehash(), hash)
+		}
+	})
+}
+
+// TestPasswordNeedsRehashBcryptCost changes the package-level password hashing settings, so
+// it isn't parallel.
+func TestPasswordNeedsRehashBcryptCost(t *testing.T) {
+	lowCost := DefaultPasswordHashing
+	lowCost.BcryptCost = 4
+	setTestPasswordHashing(t, lowCost)
+
+	var p password
+	require.NoError(t, p.Set("pa55word1234"))
+	assert.True(t, strings.HasPrefix(string(p.hash), "$2a$04$"))
+	assert.False(t, p.NeedsRehash())
+
+	higherCost := DefaultPasswordHashing
+	higherCost.BcryptCost = 5
+	setTestPasswordHashing(t, higherCost)
+	assert.True(t, p.NeedsRehash())
+
+	matches, err := p.Matches("pa55word1234")
+	require.NoError(t, err)
+	assert.True(t, matches)
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryCounter is a pgx tracer that counts the queries sent to the database, including
+// statements run inside transactions and queries queued in batches. Queries are counted
+// in the QueryCount carried by their context, so that the queries of a single request can
+// be counted while other requests run concurrently. Tests use it to catch N+1 regressions,
+// such as per-row lookups creeping back into list queries.
+type QueryCounter struct{}
+
+// QueryCount is the number of queries run with a context returned by WithQueryCount.
+// It is safe for concurrent use.
+type QueryCount struct {
+	count atomic.Int64
+}
+
+// Load returns the number of queries counted so far.
+func (c *QueryCount) Load() int64 {
+	return c.count.Load()
+}
+
+type queryCountContextKey struct{}
+
+// WithQueryCount returns a copy of ctx in which the queries traced by a QueryCounter are
+// counted, together with the count.
+func WithQueryCount(ctx context.Context) (context.Context, *QueryCount) {
+	count := &QueryCount{}
+	return context.WithValue(ctx, queryCountContextKey{}, count), count
+}
+
+// countQuery counts a query run with ctx, if ctx carries a QueryCount.
+func countQuery(ctx context.Context) {
+	if count, ok := ctx.Value(queryCountContextKey{}).(*QueryCount); ok {
+		count.count.Add(1)
+	}
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (c *QueryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	countQuery(ctx)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (c *QueryCounter) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (c *QueryCounter) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return ctx
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. Every query in a batch is counted, as each
+// is a separate statement for the database to run.
+func (c *QueryCounter) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchQueryData) {
+	countQuery(ctx)
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (c *QueryCounter) TraceBatchEnd(context.Context, *pgx.Conn, pgx.TraceBatchEndData) {}
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCounter(t *testing.T) {
+	t.Parallel()
+
+	var tracer QueryCounter
+	ctx, count := WithQueryCount(context.Background())
+	otherCtx, otherCount := WithQueryCount(context.Background())
+
+	tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{})
+	tracer.TraceQueryStart(otherCtx, nil, pgx.TraceQueryStartData{})
+
+	// Every query queued in a batch is counted
+	batchCtx := tracer.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{})
+	tracer.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{})
+	tracer.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{})
+	tracer.TraceBatchEnd(batchCtx, nil, pgx.TraceBatchEndData{})
+
+	// Queries run without a count aren't counted anywhere
+	tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{})
+
+	assert.EqualValues(t, 3, count.Load())
+	assert.EqualValues(t, 1, otherCount.Load())
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy controls how read-only queries are retried after transient database errors.
+// The zero value disables retries.
+type RetryPolicy struct {
+	Attempts int           // Total number of attempts, including the first (values below 1 mean one attempt)
+	Backoff  time.Duration // Delay before the first retry; doubled after every further attempt
+}
+
+// isTransientError reports whether err looks like a dropped or reset connection, in which
+// case a read that failed may succeed when tried again. Logical errors such as
+// ErrRecordNotFound, constraint violations and timeouts are never transient.
+func isTransientError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrRecordNotFound),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is "connection exception"; 57P01-57P03 mean the server is shutting down
+		// or cannot accept connections yet. Everything else is a genuine query error.
+		return pgErr.Code[:2] == "08" || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// retryRead runs the read-only operation fn, retrying it with exponential backoff while it
+// fails with a transient error and attempts remain. Every attempt gets its own timeout,
+// derived from parent, so that a slow failed attempt doesn't eat into the next one and
+// cancelling parent, for example when the client disconnects, aborts the read and stops
+// any further retries. fn must be safe to run more than once, so it should reset any
+// state it accumulates.
+func retryRead(parent context.Context, policy RetryPolicy, timeout time.Duration, fn func(ctx context.Context) error) error {
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		err := fn(ctx)
+		cancel()
+
+		if attempt >= policy.Attempts || !isTransientError(err) {
+			return err
+		}
+
+		select {
+		case <-parent.Done():
+			return parent.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"No error", nil, false},
+		{"Record not found", ErrRecordNotFound, false},
+		{"Unique violation", &pgconn.PgError{Code: pgUniqueViolation}, false},
+		{"Foreign key violation", &pgconn.PgError{Code: pgForeignKeyViolation}, false},
+		{"Timeout", context.DeadlineExceeded, false},
+		{"Connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"Server shutting down", &pgconn.PgError{Code: "57P01"}, true},
+		{"Connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, isTransientError(tc.err))
+		})
+	}
+}
+
+func TestRetryRead(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	connReset := fmt.Errorf("read: %w", syscall.ECONNRESET)
+
+	t.Run("Flaky query succeeds on retry", func(t *testing.T) {
+		calls := 0
+		var tags []string
+		err := retryRead(context.Background(), policy, time.Second, func(ctx context.Context) error {
+			calls++
+			if calls == 1 {
+				return connReset
+			}
+			tags = []string{"go", "postgres"}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, []string{"go", "postgres"}, tags)
+	})
+
+	t.Run("Logical errors are not retried", func(t *testing.T) {
+		calls := 0
+		err := retryRead(context.Background(), policy, time.Second, func(ctx context.Context) error {
+			calls++
+			return ErrRecordNotFound
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Gives up after the configured attempts", func(t *testing.T) {
+		calls := 0
+		err := retryRead(context.Background(), policy, time.Second, func(ctx context.Context) error {
+			calls++
+			return connReset
+		})
+		require.True(t, errors.Is(err, syscall.ECONNRESET))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Zero policy makes a single attempt", func(t *testing.T) {
+		calls := 0
+		err := retryRead(context.Background(), RetryPolicy{}, time.Second, func(ctx context.Context) error {
+			calls++
+			return connReset
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Cancelled parent stops retries", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := retryRead(parent, RetryPolicy{Attempts: 3, Backoff: time.Minute}, time.Second, func(ctx context.Context) error {
+			calls++
+			cancel()
+			return connReset
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
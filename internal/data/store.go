@@ -1,9 +1,11 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -12,74 +14,175 @@ var (
 	ErrEditConflict   = errors.New("edit conflict")
 )
 
+// PostgreSQL error codes (SQLSTATE) that the stores translate into domain errors.
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// emptyIfNil returns s, or an empty slice if s is nil, so that collections always
+// marshal to [] rather than null in JSON responses.
+func emptyIfNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// isPgError reports whether err is a PostgreSQL error with the given SQLSTATE code.
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// ModelStore groups the stores used by the handlers. Every store method takes the context
+// of the request it serves, so that its queries give up when the request is cancelled and
+// can be attributed to the request, and applies its own timeout on top.
 type ModelStore struct {
-	Users    UserStoreInterface
-	Articles ArticleStoreInterface
-	Tags     TagStoreInterface
-	Comments CommentStoreInterface
+	Users      UserStoreInterface
+	Articles   ArticleStoreInterface
+	Tags       TagStoreInterface
+	Comments   CommentStoreInterface
+	Migrations MigrationStoreInterface
+}
+
+// Options holds optional limits and policies enforced by the stores.
+// The zero value disables all of them.
+type Options struct {
+	MaxFollows         int         // Maximum number of users a single user may follow (0 means unlimited)
+	MaxArticles        int         // Maximum number of articles a single user may own (0 means unlimited)
+	ForbidSelfFavorite bool        // Reject users favoriting their own articles
+	MaxSlugLength      int         // Maximum length of the title part of article slugs (0 means unlimited)
+	ReadRetry          RetryPolicy // Retry policy for read-only queries that fail with transient errors
+	TagPolicy          string      // Duplicate tag policy, one of TagPolicies; empty means TagPolicyReject
+
+	// ComputedFavoritesCount counts favorites on read instead of maintaining a
+	// counter on the article row, reducing write contention on hot articles.
+	ComputedFavoritesCount bool
 }
 
-func NewModelStore(db *pgxpool.Pool, timeout time.Duration, userCache *UserCache) ModelStore {
+func NewModelStore(db *pgxpool.Pool, timeout time.Duration, userCache *UserCache, opts Options) ModelStore {
 	return ModelStore{
-		Users:    &UserStore{db: db, timeout: timeout, userCache: userCache},
-		Articles: &ArticleStore{db: db, timeout: timeout},
-		Tags:     &TagStore{db: db, timeout: timeout},
-		Comments: &CommentStore{db: db, timeout: timeout},
+		Users: &UserStore{db: db, timeout: timeout, retry: opts.ReadRetry, userCache: userCache, maxFollows: opts.MaxFollows},
+		Articles: &ArticleStore{
+			db:                     db,
+			timeout:                timeout,
+			retry:                  opts.ReadRetry,
+			forbidSelfFavorite:     opts.ForbidSelfFavorite,
+			maxSlugLength:          opts.MaxSlugLength,
+			maxArticles:            opts.MaxArticles,
+			tagPolicy:              opts.TagPolicy,
+			computedFavoritesCount: opts.ComputedFavoritesCount,
+		},
+		Tags:       &TagStore{db: db, timeout: timeout, retry: opts.ReadRetry},
+		Comments:   &CommentStore{db: db, timeout: timeout, retry: opts.ReadRetry},
+		Migrations: &MigrationStore{db: db},
 	}
 }
 
 type UserStoreInterface interface {
 	// Insert a new record into the users table.
-	Insert(user *User) error
+	Insert(ctx context.Context, user *User) error
 	// GetByEmail returns a specific record from the users table.
-	GetByEmail(email string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
 	// GetByID retrieves a specific record from the users table by ID.
-	GetByID(id int64) (*User, error)
+	GetByID(ctx context.Context, id int64) (*User, error)
 	// GetByUsername retrieves a specific record from the users table by username.
-	GetByUsername(username string) (*User, error)
-	// FollowUser records that a user is following another user
-	FollowUser(followerID, followedID int64) error
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	// FollowUser records that a user is following another user and reports whether the
+	// follow is new
+	FollowUser(ctx context.Context, followerID, followedID int64) (bool, error)
 	// UnfollowUser records that a user has unfollowed another user
-	UnfollowUser(followerID, followedID int64) error
+	UnfollowUser(ctx context.Context, followerID, followedID int64) error
 	// IsFollowing checks if a user is following another user
-	IsFollowing(followerID, followedID int64) (bool, error)
+	IsFollowing(ctx context.Context, followerID, followedID int64) (bool, error)
+	// ListFollowers returns a page of the profiles following a user, and the total number of followers.
+	ListFollowers(ctx context.Context, userID int64, limit, offset int, viewerID int64) ([]Profile, int, error)
+	// ListFollowing returns a page of the profiles a user follows, and the total number of followed users.
+	ListFollowing(ctx context.Context, userID int64, limit, offset int, viewerID int64) ([]Profile, int, error)
+	// CountFollowing returns the number of users a user follows.
+	CountFollowing(ctx context.Context, followerID int64) (int, error)
+	// NotificationCounts counts new followers, and new comments and favorites on a user's articles, since a time.
+	NotificationCounts(ctx context.Context, userID int64, since time.Time) (*NotificationCounts, error)
+	// GetFollowingStatus reports whether a user follows each of the given usernames.
+	GetFollowingStatus(ctx context.Context, followerID int64, usernames []string) (map[string]bool, error)
 	// Update an existing user record.
-	Update(user *User) error
+	Update(ctx context.Context, user *User) error
+	// RehashPassword replaces the user's password hash with one using the configured algorithm.
+	RehashPassword(ctx context.Context, user *User, plaintextPassword string) error
 }
 
 type ArticleStoreInterface interface {
 	// InsertAndReturn inserts an article and returns the complete article with author details in a single query.
 	// This is more efficient than Insert followed by GetBySlug as it eliminates an extra database round trip.
-	InsertAndReturn(article *Article, currentUser *User) (*Article, error)
+	InsertAndReturn(ctx context.Context, article *Article, currentUser *User) (*Article, error)
 	// GetIDBySlug retrieves just the article ID by its slug (lightweight alternative to GetBySlug).
-	GetIDBySlug(slug string) (int64, error)
+	GetIDBySlug(ctx context.Context, slug string) (int64, error)
+	// WasDeleted reports whether an article with the slug was deleted, according to the audit log.
+	WasDeleted(ctx context.Context, slug string) (bool, error)
+	// CountByAuthor returns the number of articles owned by an author.
+	CountByAuthor(ctx context.Context, authorID int64) (int, error)
 	// GetBySlug retrieves a specific record from the articles table by slug.
-	GetBySlug(slug string, currentUser *User) (*Article, error)
+	GetBySlug(ctx context.Context, slug string, currentUser *User) (*Article, error)
+	// GetBySlugWithComments retrieves an article, its latest comments and its total number
+	// of comments in a single round trip.
+	GetBySlugWithComments(ctx context.Context, slug string, currentUser *User, commentLimit int) (*Article, []Comment, int, error)
+	// GetBySlugs retrieves the articles with the given slugs, in the given order, omitting missing slugs.
+	GetBySlugs(ctx context.Context, slugs []string, currentUser *User) ([]Article, error)
 	// List retrieves articles with optional filtering and pagination.
-	List(filters ArticleFilters, currentUser *User) ([]Article, int, error)
+	List(ctx context.Context, filters ArticleFilters, currentUser *User) ([]Article, int, error)
+	// CommentedBy retrieves the distinct articles an author has commented on, most recently commented first.
+	CommentedBy(ctx context.Context, authorID int64, limit, offset int, currentUser *User) ([]Article, int, error)
+	// Related retrieves the articles sharing the most tags with the given article.
+	Related(ctx context.Context, articleID int64, limit int, excludeSameAuthor bool, currentUser *User) ([]Article, error)
+	// Trending retrieves the most favorited articles, optionally counting only favorites from the last few days.
+	Trending(ctx context.Context, days, limit int, currentUser *User) ([]Article, error)
 	// FavoriteBySlug favorites the article with the given slug for the user and returns the updated article.
-	FavoriteBySlug(slug string, userID int64) (*Article, error)
+	// A non-zero expectedVersion must match the article's version.
+	FavoriteBySlug(ctx context.Context, slug string, userID int64, expectedVersion int) (*Article, error)
 	// UnfavoriteBySlug unfavorites the article with the given slug for the user and returns the updated article.
-	UnfavoriteBySlug(slug string, userID int64) (*Article, error)
+	// A non-zero expectedVersion must match the article's version.
+	UnfavoriteBySlug(ctx context.Context, slug string, userID int64, expectedVersion int) (*Article, error)
+	// FavoriteByID is FavoriteBySlug for the article with the given ID.
+	FavoriteByID(ctx context.Context, id, userID int64, expectedVersion int) (*Article, error)
+	// UnfavoriteByID is UnfavoriteBySlug for the article with the given ID.
+	UnfavoriteByID(ctx context.Context, id, userID int64, expectedVersion int) (*Article, error)
 	// DeleteBySlug deletes the article with the given slug.
-	DeleteBySlug(slug string, userID int64) error
+	DeleteBySlug(ctx context.Context, slug string, userID int64) error
 	// Update an existing article record.
-	Update(article *Article) error
-	// InsertTags inserts tags into the tags table (used for async operations).
-	InsertTags(tags ...string) error
+	Update(ctx context.Context, article *Article) error
+	// InsertTags inserts tags into the tags table and returns the tags that didn't exist yet.
+	InsertTags(ctx context.Context, tags ...string) ([]string, error)
 }
 
 type TagStoreInterface interface {
 	// GetAll retrieves all tags from the tags table.
-	GetAll() ([]string, error)
+	GetAll(ctx context.Context) ([]string, error)
+	// GetByAuthor returns the distinct tags across an author's articles with their counts.
+	GetByAuthor(ctx context.Context, authorID int64) ([]TagCount, error)
+}
+
+type MigrationStoreInterface interface {
+	// Status returns the version and dirty state of the applied schema migrations.
+	Status(ctx context.Context) (*MigrationStatus, error)
 }
 
 type CommentStoreInterface interface {
 	// InsertAndReturn inserts a comment and returns it with author details populated from currentUser.
 	// Uses the currentUser from context instead of querying the database for author information.
-	InsertAndReturn(comment *Comment, currentUser *User) (*Comment, error)
-	// GetByArticleID retrieves all comments with author details for an article by its article ID.
-	GetByArticleID(articleID int64) ([]Comment, error)
+	// It also returns the article's number of comments, including the new one.
+	InsertAndReturn(ctx context.Context, comment *Comment, currentUser *User) (*Comment, int, error)
+	// GetByArticleID retrieves a page of an article's comments with author details, newest first,
+	// together with the article's total number of comments.
+	GetByArticleID(ctx context.Context, articleID int64, limit, offset int) ([]Comment, int, error)
+	// GetByID retrieves a single comment with author details, scoped to the given article.
+	GetByID(ctx context.Context, articleID, id int64) (*Comment, error)
+	// Update updates a comment's body and body type, only if it was written by authorID.
+	Update(ctx context.Context, comment *Comment, authorID int64) error
+	// DeleteByID deletes a comment on the given article, only if it was written by authorID.
+	DeleteByID(ctx context.Context, articleID, commentID, authorID int64) error
+	// GetRecentBySlugs retrieves the most recent comments on each of the given articles, grouped by slug.
+	GetRecentBySlugs(ctx context.Context, slugs []string, perArticle int, currentUserID int64) (map[string][]Comment, error)
 	// SetFollowingStatus efficiently checks and sets the following status for all comment authors.
-	SetFollowingStatus(comments []Comment, currentUserID int64) error
+	SetFollowingStatus(ctx context.Context, comments []Comment, currentUserID int64) error
 }
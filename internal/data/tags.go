@@ -12,17 +12,17 @@ import (
 type TagStore struct {
 	db      *pgxpool.Pool
 	timeout time.Duration
+	retry   RetryPolicy
 }
 
-// GetAll retrieves all tags from the database.
-func (s *TagStore) GetAll() ([]string, error) {
+// GetAll retrieves all tags from the database. The query is cancelled along with ctx.
+func (s *TagStore) GetAll(ctx context.Context) ([]string, error) {
 	query := `SELECT ARRAY_AGG(tag ORDER BY tag) FROM tags`
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
 	var tags []string
-	err := s.db.QueryRow(ctx, query).Scan(&tags)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query).Scan(&tags)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return []string{}, nil // Return empty slice if no tags exist
@@ -31,9 +31,46 @@ func (s *TagStore) GetAll() ([]string, error) {
 	}
 
 	// Handle case where no tags exist (ARRAY_AGG returns NULL)
-	if tags == nil {
-		return []string{}, nil
+	return emptyIfNil(tags), nil
+}
+
+// TagCount is a tag together with the number of articles using it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// GetByAuthor returns the distinct tags used across the author's articles, with the number
+// of the author's articles using each tag, ordered by count and then by tag.
+func (s *TagStore) GetByAuthor(ctx context.Context, authorID int64) ([]TagCount, error) {
+	query := `
+		SELECT tag, COUNT(*)
+		FROM articles, unnest(articles.tag_list) AS tag
+		WHERE articles.author_id = $1
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag`
+
+	var tags []TagCount
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, authorID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		tags = nil
+		for rows.Next() {
+			var tag TagCount
+			if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+				return err
+			}
+			tags = append(tags, tag)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return tags, nil
+	return emptyIfNil(tags), nil
 }
@@ -2,31 +2,36 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/manas-solves/realworld-backend/internal/validator"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrDuplicateEmail    = errors.New("duplicate email")
-	ErrDuplicateUsername = errors.New("duplicate username")
+	ErrDuplicateEmail      = errors.New("duplicate email")
+	ErrDuplicateUsername   = errors.New("duplicate username")
+	ErrFollowLimitExceeded = errors.New("follow limit exceeded")
 )
 
 var AnonymousUser = &User{}
 
 type User struct {
-	ID       int64    `json:"-"`
-	Username string   `json:"username"`
-	Email    string   `json:"email"`
-	Password password `json:"-"`
-	Image    string   `json:"image"`
-	Bio      string   `json:"bio"`
-	Token    string   `json:"token"`
-	Version  int      `json:"-"`
+	ID              int64    `json:"-"`
+	Username        string   `json:"username"`
+	Email           string   `json:"email"`
+	Password        password `json:"-"`
+	Image           string   `json:"image"`
+	Bio             string   `json:"bio"`
+	FavoritesPublic bool     `json:"favoritesPublic"` // Whether other users can list the user's favorites
+	Token           string   `json:"token"`
+	ArticlesCount   *int     `json:"articlesCount,omitempty"` // Number of articles authored, only set when requested
+	Version         int      `json:"-"`
 }
 
 // Profile represents a user's public profile with follow status.
@@ -37,6 +42,40 @@ type Profile struct {
 	Following bool   `json:"following"`
 }
 
+// defaultImage is returned in place of an empty user image when serializing users and
+// profiles. It is never stored, so users can always clear their image back to it.
+var defaultImage string
+
+// SetDefaultImage sets the image URL returned for users without an image. It is not safe
+// for concurrent use and should only be called during application startup.
+func SetDefaultImage(url string) {
+	defaultImage = url
+}
+
+// imageOrDefault returns image, or the configured default image if it is empty.
+func imageOrDefault(image string) string {
+	if image == "" {
+		return defaultImage
+	}
+	return image
+}
+
+// MarshalJSON applies the default image before encoding the user.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	out := user(u)
+	out.Image = imageOrDefault(out.Image)
+	return json.Marshal(out)
+}
+
+// MarshalJSON applies the default image before encoding the profile.
+func (p Profile) MarshalJSON() ([]byte, error) {
+	type profile Profile
+	out := profile(p)
+	out.Image = imageOrDefault(out.Image)
+	return json.Marshal(out)
+}
+
 // IsAnonymous returns true if the user is the special AnonymousUser user.
 func (u *User) IsAnonymous() bool {
 	return u == AnonymousUser
@@ -47,46 +86,42 @@ func (u *User) ToProfile(following bool) Profile {
 	return Profile{
 		Username:  u.Username,
 		Bio:       u.Bio,
-		Image:     u.Image,
+		Image:     imageOrDefault(u.Image),
 		Following: following,
 	}
 }
 
-type password struct {
-	plaintext *string
-	hash      []byte
-}
+// emailValidator is used by ValidateEmail. It defaults to the regex based validator and
+// may be replaced once at startup via SetEmailValidator.
+var emailValidator validator.EmailValidator = validator.RegexEmailValidator{}
 
-func (p *password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
-	if err != nil {
-		return err
-	}
+// SetEmailValidator replaces the validator used by ValidateEmail. It is not safe for
+// concurrent use and should only be called during application startup.
+func SetEmailValidator(ev validator.EmailValidator) {
+	emailValidator = ev
+}
 
-	p.plaintext = &plaintextPassword
-	p.hash = hash
+// requireHTTPSImages makes ValidateUser reject image URLs that are not https. It may be
+// enabled once at startup via SetRequireHTTPSImages.
+var requireHTTPSImages bool
 
-	return nil
+// SetRequireHTTPSImages controls whether ValidateUser only accepts https image URLs. It is
+// not safe for concurrent use and should only be called during application startup.
+func SetRequireHTTPSImages(require bool) {
+	requireHTTPSImages = require
 }
 
-// Matches compares the plaintext password against the hash and returns true if they match.
-func (p *password) Matches(plaintextPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintextPassword))
-	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
-		}
+// ValidateImageURL checks an image URL is https when https-only images are required. An
+// empty image is always valid since it clears the image.
+func ValidateImageURL(v *validator.Validator, image string) {
+	if requireHTTPSImages && image != "" {
+		v.Check(validator.HTTPSURL(image), "image must be an https URL")
 	}
-
-	return true, nil
 }
 
 func ValidateEmail(v *validator.Validator, email string) {
 	v.Check(email != "", "email must be provided")
-	v.Check(validator.Matches(email, validator.EmailRX), "email must be a valid email address")
+	v.Check(emailValidator.Valid(email), "email must be a valid email address")
 }
 
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
@@ -96,12 +131,18 @@ func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 }
 
 // ValidateUser checks the values provided by the user are valid. It performs validation on the
-// Name, Email and Password fields.
+// Name, Email, Password and Image fields.
+// UsernameAliasMe is accepted by the API in place of a username to refer to the
+// authenticated user, so no user may register it as their username.
+const UsernameAliasMe = "me"
+
 func ValidateUser(v *validator.Validator, user User) {
 	v.Check(user.Username != "", "username must be provided")
 	v.Check(len(user.Username) <= 500, "name must not be more than 500 bytes long")
+	v.Check(!strings.EqualFold(user.Username, UsernameAliasMe), `username "me" is reserved`)
 
 	ValidateEmail(v, user.Email)
+	ValidateImageURL(v, user.Image)
 
 	if user.Password.plaintext != nil {
 		ValidatePasswordPlaintext(v, *user.Password.plaintext)
@@ -114,50 +155,59 @@ func ValidateUser(v *validator.Validator, user User) {
 }
 
 type UserStore struct {
-	db        *pgxpool.Pool
-	timeout   time.Duration
-	userCache *UserCache
+	db         *pgxpool.Pool
+	timeout    time.Duration
+	retry      RetryPolicy
+	userCache  *UserCache
+	maxFollows int
 }
 
 // Insert adds a new record in the users table.
-func (s UserStore) Insert(user *User) error {
+func (s UserStore) Insert(ctx context.Context, user *User) error {
 	query := `
 		INSERT INTO users (username, email, password_hash, image, bio) 
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id`
+		RETURNING id, favorites_public`
 
 	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, args...).Scan(&user.ID)
+	err := s.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.FavoritesPublic)
 	if err != nil {
-		switch {
-		case err.Error() == `ERROR: duplicate key value violates unique constraint "users_email_key" (SQLSTATE 23505)`:
+		return duplicateUserError(err)
+	}
+	return nil
+}
+
+// duplicateUserError maps a unique violation on the users table to ErrDuplicateEmail or
+// ErrDuplicateUsername. Any other error is returned unchanged.
+func duplicateUserError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		switch pgErr.ConstraintName {
+		case "users_email_key":
 			return ErrDuplicateEmail
-		case err.Error() == `ERROR: duplicate key value violates unique constraint "users_username_key" (SQLSTATE 23505)`:
+		case "users_username_key":
 			return ErrDuplicateUsername
-		default:
-			return err
 		}
 	}
-	return nil
+	return err
 }
 
 // GetByEmail retrieves a user by their email address.
-func (s UserStore) GetByEmail(email string) (*User, error) {
+func (s UserStore) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, image, bio, version
+		SELECT id, username, email, password_hash, image, bio, favorites_public, version
 		FROM users
 		WHERE email = $1`
 
 	var user User
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.Image, &user.Bio, &user.Version)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.Image, &user.Bio, &user.FavoritesPublic, &user.Version)
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -172,7 +222,7 @@ func (s UserStore) GetByEmail(email string) (*User, error) {
 
 // GetByID retrieves a user by their ID from the database.
 // Uses cache if available, otherwise queries the database and caches the result.
-func (s UserStore) GetByID(id int64) (*User, error) {
+func (s UserStore) GetByID(ctx context.Context, id int64) (*User, error) {
 	// Try to get from cache first if cache is available
 	if s.userCache != nil {
 		if user, found := s.userCache.Get(id); found {
@@ -181,24 +231,24 @@ func (s UserStore) GetByID(id int64) (*User, error) {
 	}
 
 	query := `
-		SELECT id, username, email, password_hash, image, bio, version
+		SELECT id, username, email, password_hash, image, bio, favorites_public, version
 		FROM users
 		WHERE id = $1`
 
 	var user User
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.Password.hash,
-		&user.Image,
-		&user.Bio,
-		&user.Version,
-	)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, id).Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Password.hash,
+			&user.Image,
+			&user.Bio,
+			&user.FavoritesPublic,
+			&user.Version,
+		)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrRecordNotFound
@@ -215,21 +265,21 @@ func (s UserStore) GetByID(id int64) (*User, error) {
 }
 
 // GetByUsername retrieves a user by their username from the database.
-func (s UserStore) GetByUsername(username string) (*User, error) {
-	query := `SELECT id, username, email, image, bio, version FROM users WHERE username = $1`
+func (s UserStore) GetByUsername(ctx context.Context, username string) (*User, error) {
+	query := `SELECT id, username, email, image, bio, favorites_public, version FROM users WHERE username = $1`
 	var user User
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.Image,
-		&user.Bio,
-		&user.Version,
-	)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, username).Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Image,
+			&user.Bio,
+			&user.FavoritesPublic,
+			&user.Version,
+		)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrRecordNotFound
@@ -240,51 +290,251 @@ func (s UserStore) GetByUsername(username string) (*User, error) {
 }
 
 // FollowUser creates a follow relationship between two users.
-func (s UserStore) FollowUser(followerID, followedID int64) error {
+// If a follow limit is configured, it returns ErrFollowLimitExceeded when the follower
+// already follows the maximum number of users. Re-following an existing follow is
+// always allowed. If either user no longer exists (e.g. it was deleted after being looked
+// up), ErrRecordNotFound is returned. The returned bool reports whether a new follow was
+// created, as opposed to the follow already existing.
+func (s UserStore) FollowUser(ctx context.Context, followerID, followedID int64) (bool, error) {
 	if followerID == followedID {
-		return errors.New("cannot follow yourself")
+		return false, errors.New("cannot follow yourself")
 	}
-	query := `INSERT INTO follows (follower_id, followed_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	_, err := s.db.Exec(ctx, query, followerID, followedID)
-	return err
+
+	insertQuery := `INSERT INTO follows (follower_id, followed_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	var created bool
+	var err error
+	if s.maxFollows > 0 {
+		err = pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+			// Lock the follower so that concurrent follows by the same user are checked
+			// against the limit one at a time
+			var locked int64
+			err := tx.QueryRow(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, followerID).Scan(&locked)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrRecordNotFound
+				}
+				return err
+			}
+
+			query := `
+				SELECT COUNT(*), COALESCE(BOOL_OR(followed_id = $2), false)
+				FROM follows
+				WHERE follower_id = $1`
+			var count int
+			var alreadyFollowing bool
+			err = tx.QueryRow(ctx, query, followerID, followedID).Scan(&count, &alreadyFollowing)
+			if err != nil {
+				return err
+			}
+			if !alreadyFollowing && count >= s.maxFollows {
+				return ErrFollowLimitExceeded
+			}
+
+			tag, err := tx.Exec(ctx, insertQuery, followerID, followedID)
+			if err != nil {
+				return err
+			}
+			created = tag.RowsAffected() > 0
+			return nil
+		})
+	} else {
+		var tag pgconn.CommandTag
+		tag, err = s.db.Exec(ctx, insertQuery, followerID, followedID)
+		created = err == nil && tag.RowsAffected() > 0
+	}
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return false, ErrRecordNotFound
+		}
+		return false, err
+	}
+	return created, nil
 }
 
 // UnfollowUser removes a follow relationship between two users.
-func (s UserStore) UnfollowUser(followerID, followedID int64) error {
+func (s UserStore) UnfollowUser(ctx context.Context, followerID, followedID int64) error {
 	query := `DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	_, err := s.db.Exec(ctx, query, followerID, followedID)
 	return err
 }
 
 // IsFollowing checks if followerID is following followedID.
-func (s UserStore) IsFollowing(followerID, followedID int64) (bool, error) {
+func (s UserStore) IsFollowing(ctx context.Context, followerID, followedID int64) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followed_id = $2)`
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
 	var exists bool
-	err := s.db.QueryRow(ctx, query, followerID, followedID).Scan(&exists)
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, followerID, followedID).Scan(&exists)
+	})
 	return exists, err
 }
 
+// NotificationCounts holds the number of events relevant to a user since a point in time.
+type NotificationCounts struct {
+	NewFollowers int `json:"newFollowers"`
+	NewComments  int `json:"newComments"`  // Comments by others on the user's articles
+	NewFavorites int `json:"newFavorites"` // Favorites by others of the user's articles
+}
+
+// NotificationCounts counts the follows of userID, and the comments and favorites by other
+// users on articles authored by userID, made after since.
+func (s UserStore) NotificationCounts(ctx context.Context, userID int64, since time.Time) (*NotificationCounts, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM follows f
+			 WHERE f.followed_id = $1 AND f.created_at > $2),
+			(SELECT COUNT(*) FROM comments c
+			 JOIN articles a ON c.article_id = a.id
+			 WHERE a.author_id = $1 AND c.author_id <> $1 AND c.created_at > $2),
+			(SELECT COUNT(*) FROM favorites fav
+			 JOIN articles a ON fav.article_id = a.id
+			 WHERE a.author_id = $1 AND fav.user_id <> $1 AND fav.created_at > $2)`
+
+	var counts NotificationCounts
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, userID, since.UTC()).Scan(&counts.NewFollowers, &counts.NewComments, &counts.NewFavorites)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+// ListFollowers returns a page of the profiles of the users following userID, most recent
+// follow first, together with the total number of followers. Following is set relative
+// to viewerID (0 for anonymous viewers).
+func (s UserStore) ListFollowers(ctx context.Context, userID int64, limit, offset int, viewerID int64) ([]Profile, int, error) {
+	query := `
+		SELECT u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM follows v WHERE v.follower_id = $4 AND v.followed_id = u.id),
+		       COUNT(*) OVER()
+		FROM follows f
+		JOIN users u ON f.follower_id = u.id
+		WHERE f.followed_id = $1
+		ORDER BY f.created_at DESC, u.id
+		LIMIT $2 OFFSET $3`
+
+	return s.listFollowProfiles(ctx, query, userID, limit, offset, viewerID)
+}
+
+// ListFollowing returns a page of the profiles of the users userID follows, most recent
+// follow first, together with the total number of followed users. Following is set
+// relative to viewerID (0 for anonymous viewers).
+func (s UserStore) ListFollowing(ctx context.Context, userID int64, limit, offset int, viewerID int64) ([]Profile, int, error) {
+	query := `
+		SELECT u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM follows v WHERE v.follower_id = $4 AND v.followed_id = u.id),
+		       COUNT(*) OVER()
+		FROM follows f
+		JOIN users u ON f.followed_id = u.id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC, u.id
+		LIMIT $2 OFFSET $3`
+
+	return s.listFollowProfiles(ctx, query, userID, limit, offset, viewerID)
+}
+
+// listFollowProfiles runs a ListFollowers or ListFollowing query and scans the profiles
+// and the total count from its rows.
+func (s UserStore) listFollowProfiles(ctx context.Context, query string, userID int64, limit, offset int, viewerID int64) ([]Profile, int, error) {
+	var profiles []Profile
+	var totalCount int
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, userID, limit, offset, viewerID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		profiles = nil
+		for rows.Next() {
+			var profile Profile
+			if err := rows.Scan(&profile.Username, &profile.Bio, &profile.Image, &profile.Following, &totalCount); err != nil {
+				return err
+			}
+			profiles = append(profiles, profile)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return emptyIfNil(profiles), totalCount, nil
+}
+
+// CountFollowing returns the number of users followerID follows.
+func (s UserStore) CountFollowing(ctx context.Context, followerID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM follows WHERE follower_id = $1`
+	var count int
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		return s.db.QueryRow(ctx, query, followerID).Scan(&count)
+	})
+	return count, err
+}
+
+// GetFollowingStatus reports, for each of the given usernames, whether followerID follows
+// that user. Usernames are matched case-insensitively and unknown usernames map to false.
+// The result is keyed by the usernames exactly as they were passed in.
+func (s UserStore) GetFollowingStatus(ctx context.Context, followerID int64, usernames []string) (map[string]bool, error) {
+	query := `
+		SELECT u.username
+		FROM follows f
+		JOIN users u ON f.followed_id = u.id
+		WHERE f.follower_id = $1 AND u.username = ANY($2::citext[])`
+
+	var followed map[string]bool
+	err := retryRead(ctx, s.retry, s.timeout, func(ctx context.Context) error {
+		rows, err := s.db.Query(ctx, query, followerID, usernames)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		followed = make(map[string]bool)
+		for rows.Next() {
+			var username string
+			if err := rows.Scan(&username); err != nil {
+				return err
+			}
+			followed[strings.ToLower(username)] = true
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		status[username] = followed[strings.ToLower(username)]
+	}
+
+	return status, nil
+}
+
 // Update updates an existing user record in the database.
 // Invalidates the cache for the updated user.
-func (s UserStore) Update(user *User) error {
+func (s UserStore) Update(ctx context.Context, user *User) error {
 	query := `
 		UPDATE users
-		SET username = $1, email = $2, password_hash = $3, image = $4, bio = $5, version = version + 1
-		WHERE id = $6
+		SET username = $1, email = $2, password_hash = $3, image = $4, bio = $5, favorites_public = $6,
+		    version = version + 1
+		WHERE id = $7
 		RETURNING version`
-	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio, user.ID}
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio, user.FavoritesPublic, user.ID}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	err := s.db.QueryRow(ctx, query, args...).Scan(&user.Version)
 	if err != nil {
-		return err
+		// Another user may have taken the username or email since it was validated
+		return duplicateUserError(err)
 	}
 
 	// Invalidate cache after successful update
@@ -294,3 +544,35 @@ func (s UserStore) Update(user *User) error {
 
 	return nil
 }
+
+// RehashPassword replaces the user's password hash with one created by the configured
+// algorithm. The update only applies while the stored hash is unchanged, so a concurrent
+// password change isn't overwritten; in that case the user is left as is.
+func (s UserStore) RehashPassword(ctx context.Context, user *User, plaintextPassword string) error {
+	var rehashed password
+	if err := rehashed.Set(plaintextPassword); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET password_hash = $1
+		WHERE id = $2 AND password_hash = $3`
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, rehashed.hash, user.ID, user.Password.hash)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return nil
+	}
+	user.Password.hash = rehashed.hash
+
+	if s.userCache != nil {
+		s.userCache.Delete(user.ID)
+	}
+
+	return nil
+}
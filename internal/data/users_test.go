@@ -0,0 +1,37 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateUserError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	testCases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"Duplicate email", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_email_key"}, ErrDuplicateEmail},
+		{"Duplicate username", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_username_key"}, ErrDuplicateUsername},
+		{"Wrapped duplicate", fmt.Errorf("update user: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_email_key"}), ErrDuplicateEmail},
+		{"Other constraint", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_pkey"}, nil},
+		{"Other error", other, other},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := duplicateUserError(tc.err)
+			if tc.want == nil {
+				assert.Same(t, tc.err, got)
+				return
+			}
+			assert.ErrorIs(t, got, tc.want)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/manas-solves/realworld-backend/internal/sanitize"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// renderer converts GitHub Flavored Markdown to HTML. By default it omits raw HTML
+// embedded in the source.
+var renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// ToHTML renders the markdown source to sanitized HTML that is safe to embed in a page.
+func ToHTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	// Sanitize the rendered output as a second line of defence
+	return sanitize.SanitizeHTML(buf.String()), nil
+}
+
+// PlainToHTML renders plain text as a single HTML paragraph, escaping any markup.
+func PlainToHTML(text string) string {
+	return "<p>" + html.EscapeString(text) + "</p>"
+}
@@ -0,0 +1,39 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToHTML(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"Heading and emphasis", "# Title\n\nSome *emphasis* and **strong** text.", "<h1>Title</h1>\n<p>Some <em>emphasis</em> and <strong>strong</strong> text.</p>\n"},
+		{"Link", "[Conduit](https://example.com)", "<p><a href=\"https://example.com\" rel=\"nofollow\">Conduit</a></p>\n"},
+		{"Fenced code", "```go\nfmt.Println(\"hi\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;hi&#34;)\n</code></pre>\n"},
+		{"Script tag", "Hello <script>alert('xss')</script>", "<p>Hello alert(&#39;xss&#39;)</p>\n"},
+		{"Event handler", "<img src=\"x.png\" onerror=\"alert(1)\">", "\n"},
+		{"JavaScript URL", "[click](javascript:alert(1))", "<p>click</p>\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ToHTML(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.NotContains(t, got, "<script")
+			assert.NotContains(t, got, "onerror")
+			assert.NotContains(t, got, "javascript:")
+		})
+	}
+}
+
+func TestPlainToHTML(t *testing.T) {
+	assert.Equal(t, "<p>1 &lt; 2 &amp;&amp; *not emphasis*</p>", PlainToHTML("1 < 2 && *not emphasis*"))
+	assert.Equal(t, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>", PlainToHTML("<script>alert(1)</script>"))
+}
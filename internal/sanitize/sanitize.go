@@ -0,0 +1,93 @@
+// Package sanitize is the single path through which server-rendered HTML passes before it
+// is returned to clients.
+package sanitize
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// DefaultTags are the elements allowed by default: the text formatting that rendered
+// markdown needs, without images, tables or any embedded content.
+var DefaultTags = []string{
+	"a", "blockquote", "br", "code", "del", "em", "h1", "h2", "h3", "h4", "h5", "h6",
+	"hr", "li", "ol", "p", "pre", "strong", "ul",
+}
+
+// forbiddenTags can't be allowed through configuration, since they run scripts, load
+// other documents or accept input.
+var forbiddenTags = []string{
+	"applet", "base", "button", "embed", "form", "frame", "frameset", "iframe", "input",
+	"link", "math", "meta", "noscript", "object", "script", "select", "style", "svg",
+	"template", "textarea",
+}
+
+// tagNameRX matches plain HTML element names.
+var tagNameRX = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// policy is used by SanitizeHTML. It allows the default tags and may be extended once at
+// startup via SetAllowedTags.
+var policy = newPolicy(nil)
+
+// newPolicy returns a policy allowing the default tags plus the extra ones. Links may
+// only use safe URL schemes and get rel="nofollow", code blocks keep the language classes
+// that clients use for highlighting, images keep their source and alternative text, and
+// table cells their alignment. No other attributes are allowed on any element, so styles
+// and event handlers are always stripped.
+func newPolicy(extraTags []string) *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(DefaultTags...)
+	p.AllowElements(extraTags...)
+
+	p.AllowStandardURLs()
+	p.RequireNoFollowOnLinks(true)
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+
+	if slices.Contains(extraTags, "img") {
+		p.AllowAttrs("src").OnElements("img")
+		p.AllowAttrs("alt").Matching(bluemonday.Paragraph).OnElements("img")
+	}
+	for _, cell := range []string{"th", "td"} {
+		if slices.Contains(extraTags, cell) {
+			p.AllowAttrs("align").Matching(bluemonday.CellAlign).OnElements(cell)
+		}
+	}
+
+	return p
+}
+
+// SetAllowedTags allows the given elements, such as table or img, in addition to the
+// default tags. Tag names are case-insensitive and empty entries are ignored. It returns an
+// error for names that aren't plain element names or that are never allowed, such as
+// script. It is not safe for concurrent use and should only be called during application
+// startup.
+func SetAllowedTags(tags []string) error {
+	var extraTags []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if !tagNameRX.MatchString(tag) {
+			return fmt.Errorf("invalid html tag %q", tag)
+		}
+		if slices.Contains(forbiddenTags, tag) {
+			return fmt.Errorf("html tag %q can't be allowed", tag)
+		}
+		extraTags = append(extraTags, tag)
+	}
+
+	policy = newPolicy(extraTags)
+	return nil
+}
+
+// SanitizeHTML returns the HTML with everything outside the allowlist removed, so that it
+// is safe to embed in a page.
+func SanitizeHTML(html string) string {
+	return policy.Sanitize(html)
+}
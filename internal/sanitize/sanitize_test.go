@@ -0,0 +1,68 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	testCases := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"Script tag", `<p>Hello <script>alert('xss')</script></p>`, `<p>Hello </p>`},
+		{"Event handler", `<p onclick="alert(1)">Hi</p>`, `<p>Hi</p>`},
+		{"Image not allowed by default", `<p><img src="https://example.com/x.png"></p>`, `<p></p>`},
+		{"Table not allowed by default", `<table><tr><td>x</td></tr></table>`, `x`},
+		{"Inline style", `<p style="position:fixed">Hi</p>`, `<p>Hi</p>`},
+		{"JavaScript URL", `<a href="javascript:alert(1)">click</a>`, `click`},
+		{"Iframe", `<iframe src="https://evil.example.com"></iframe>`, ``},
+		{"Formatting tags", `<h1>Title</h1><p><em>a</em> <strong>b</strong> <code>c</code></p><ul><li>d</li></ul><blockquote>e</blockquote>`,
+			`<h1>Title</h1><p><em>a</em> <strong>b</strong> <code>c</code></p><ul><li>d</li></ul><blockquote>e</blockquote>`},
+		{"Safe link", `<a href="https://example.com">Conduit</a>`, `<a href="https://example.com" rel="nofollow">Conduit</a>`},
+		{"Code language class", `<pre><code class="language-go">x</code></pre>`, `<pre><code class="language-go">x</code></pre>`},
+		{"Other classes", `<code class="evil">x</code>`, `<code>x</code>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, SanitizeHTML(tc.html))
+		})
+	}
+}
+
+// TestSetAllowedTags changes the package-level policy, so it isn't parallel.
+func TestSetAllowedTags(t *testing.T) {
+	t.Cleanup(func() { policy = newPolicy(nil) })
+
+	require.NoError(t, SetAllowedTags([]string{" TABLE", "tr", "td", "", "img"}))
+
+	testCases := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"Configured tags survive", `<table><tr><td align="center">x</td></tr></table>`, `<table><tr><td align="center">x</td></tr></table>`},
+		{"Image keeps safe attributes", `<img src="https://example.com/x.png" alt="X">`, `<img src="https://example.com/x.png" alt="X">`},
+		{"Tags that aren't configured are stripped", `<details><summary>More</summary>x</details>`, `Morex`},
+		{"Event handlers are stripped", `<img src="x.png" onerror="alert(1)"><td onclick="alert(1)">x</td>`, `<img src="x.png"><td>x</td>`},
+		{"Styles are stripped", `<table style="position:fixed"><tr><td>x</td></tr></table>`, `<table><tr><td>x</td></tr></table>`},
+		{"Unsafe image source", `<img src="javascript:alert(1)">`, ``},
+		{"Default tags still allowed", `<p><em>a</em></p>`, `<p><em>a</em></p>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, SanitizeHTML(tc.html))
+		})
+	}
+
+	t.Run("Rejected tags", func(t *testing.T) {
+		for _, tags := range [][]string{{"script"}, {"table", "IFRAME"}, {"on click"}, {"<b>"}} {
+			assert.Error(t, SetAllowedTags(tags), tags)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EmailValidator decides whether an email address is acceptable.
+type EmailValidator interface {
+	Valid(email string) bool
+}
+
+// Email validation modes accepted by NewEmailValidator.
+const (
+	EmailValidationLoose  = "loose"
+	EmailValidationStrict = "strict"
+)
+
+// NewEmailValidator returns the EmailValidator for the given mode.
+func NewEmailValidator(mode string) (EmailValidator, error) {
+	switch mode {
+	case EmailValidationLoose:
+		return RegexEmailValidator{}, nil
+	case EmailValidationStrict:
+		return StrictEmailValidator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown email validation mode %q", mode)
+	}
+}
+
+// RegexEmailValidator is the default, pragmatic validator based on EmailRX.
+type RegexEmailValidator struct{}
+
+// Valid returns true if the email matches EmailRX.
+func (RegexEmailValidator) Valid(email string) bool {
+	return Matches(email, EmailRX)
+}
+
+// StrictEmailValidator applies the RFC 5321 length limits and dot-atom local-part rules
+// on top of EmailRX.
+type StrictEmailValidator struct{}
+
+// Valid returns true if the email passes EmailRX and the stricter RFC checks.
+func (StrictEmailValidator) Valid(email string) bool {
+	if !Matches(email, EmailRX) {
+		return false
+	}
+
+	// RFC 5321 limits a forward-path to 256 octets including the angle brackets.
+	if len(email) > 254 {
+		return false
+	}
+
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+
+	// The local part is limited to 64 octets and must be a dot-atom: no leading,
+	// trailing or consecutive dots.
+	if len(local) > 64 {
+		return false
+	}
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return false
+	}
+
+	// The domain is limited to 255 octets and must be fully qualified with a
+	// top-level label that is not purely numeric.
+	if len(domain) > 255 {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	tld := labels[len(labels)-1]
+	return strings.Trim(tld, "0123456789") != ""
+}
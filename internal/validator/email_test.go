@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmailValidator(t *testing.T) {
+	loose, err := NewEmailValidator(EmailValidationLoose)
+	require.NoError(t, err)
+	assert.IsType(t, RegexEmailValidator{}, loose)
+
+	strict, err := NewEmailValidator(EmailValidationStrict)
+	require.NoError(t, err)
+	assert.IsType(t, StrictEmailValidator{}, strict)
+
+	_, err = NewEmailValidator("mx")
+	require.Error(t, err)
+}
+
+func TestEmailValidators(t *testing.T) {
+	testCases := []struct {
+		name       string
+		email      string
+		wantLoose  bool
+		wantStrict bool
+	}{
+		{"Simple address", "alice@example.com", true, true},
+		{"Plus addressing", "alice+news@mail.example.co.uk", true, true},
+		{"Missing at sign", "alice.example.com", false, false},
+		{"Leading dot in local part", ".alice@example.com", true, false},
+		{"Trailing dot in local part", "alice.@example.com", true, false},
+		{"Consecutive dots in local part", "al..ice@example.com", true, false},
+		{"Unqualified domain", "alice@localhost", true, false},
+		{"Numeric top-level domain", "alice@example.123", true, false},
+		{"Local part longer than 64 octets", strings.Repeat("a", 65) + "@example.com", true, false},
+		{"Address longer than 254 octets", "alice@" + strings.Repeat("a", 62) + "." + strings.Repeat("b", 62) + "." + strings.Repeat("c", 62) + "." + strings.Repeat("d", 62) + ".com", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantLoose, RegexEmailValidator{}.Valid(tc.email), "loose")
+			assert.Equal(t, tc.wantStrict, StrictEmailValidator{}.Valid(tc.email), "strict")
+		})
+	}
+}
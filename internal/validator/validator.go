@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"net/url"
 	"regexp"
 	"slices"
 	"strings"
@@ -38,6 +39,38 @@ func (v *Validator) Check(ok bool, message string) {
 	}
 }
 
+// FormatOptions controls how validation errors are formatted for a response.
+type FormatOptions struct {
+	// Sort orders errors alphabetically so that the output does not depend on the order
+	// in which checks ran.
+	Sort bool
+}
+
+// Format returns the validator's errors formatted according to opts. See FormatErrors.
+func (v *Validator) Format(opts FormatOptions) []string {
+	return FormatErrors(v.Errors, opts)
+}
+
+// FormatErrors returns a copy of errors with surrounding whitespace trimmed and empty and
+// duplicate messages removed, keeping the first occurrence. The errors are sorted when
+// opts.Sort is set. The input slice is never modified.
+func FormatErrors(errors []string, opts FormatOptions) []string {
+	formatted := make([]string, 0, len(errors))
+	for _, message := range errors {
+		message = strings.TrimSpace(message)
+		if message == "" || slices.Contains(formatted, message) {
+			continue
+		}
+		formatted = append(formatted, message)
+	}
+
+	if opts.Sort {
+		slices.Sort(formatted)
+	}
+
+	return formatted
+}
+
 // PermittedValue returns true if a specific value is in a list of permitted values.
 func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	return slices.Contains(permittedValues, value)
@@ -59,6 +92,12 @@ func Unique[T comparable](values []T) bool {
 	return len(values) == len(uniqueValues)
 }
 
+// HTTPSURL returns true if a string is an absolute https URL with a host.
+func HTTPSURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
 // NotEmptyOrWhitespace returns true if a string is empty or contains only whitespace characters.
 func NotEmptyOrWhitespace(value string) bool {
 	return strings.TrimSpace(value) != ""
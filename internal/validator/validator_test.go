@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSURL(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  bool
+	}{
+		{"https://example.com/avatar.png", true},
+		{"HTTPS://example.com/avatar.png", true},
+		{"http://example.com/avatar.png", false},
+		{"//example.com/avatar.png", false},
+		{"/avatar.png", false},
+		{"https:///avatar.png", false},
+		{"not a url", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, HTTPSURL(tc.value), tc.value)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	errors := []string{"username must be provided", " email must be provided ", "", "username must be provided", "bio is too long"}
+
+	assert.Equal(t,
+		[]string{"username must be provided", "email must be provided", "bio is too long"},
+		FormatErrors(errors, FormatOptions{}))
+
+	// Sorting makes the output independent of the order the checks ran in
+	want := []string{"bio is too long", "email must be provided", "username must be provided"}
+	assert.Equal(t, want, FormatErrors(errors, FormatOptions{Sort: true}))
+
+	reversed := slices.Clone(errors)
+	slices.Reverse(reversed)
+	assert.Equal(t, want, FormatErrors(reversed, FormatOptions{Sort: true}))
+
+	// The input is left untouched
+	assert.Equal(t, "username must be provided", errors[0])
+
+	v := New()
+	v.AddError("username must be provided")
+	v.AddError("email must be provided")
+	assert.Equal(t, []string{"email must be provided", "username must be provided"}, v.Format(FormatOptions{Sort: true}))
+}
@@ -0,0 +1 @@
+-- Lowercasing tags is not reversible; nothing to undo.
@@ -0,0 +1,12 @@
+-- Tags are case-insensitive: store them lowercased everywhere.
+UPDATE articles
+SET tag_list = ARRAY(SELECT DISTINCT lower(t) FROM unnest(tag_list) AS t ORDER BY 1)
+WHERE tag_list IS NOT NULL
+  AND tag_list <> ARRAY(SELECT lower(t) FROM unnest(tag_list) AS t);
+
+DELETE FROM tags a
+USING tags b
+WHERE lower(a.tag) = lower(b.tag)
+  AND a.id > b.id;
+
+UPDATE tags SET tag = lower(tag) WHERE tag <> lower(tag);
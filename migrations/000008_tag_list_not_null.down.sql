@@ -0,0 +1,3 @@
+ALTER TABLE articles
+    ALTER COLUMN tag_list DROP NOT NULL,
+    ALTER COLUMN tag_list DROP DEFAULT;
@@ -0,0 +1,5 @@
+UPDATE articles SET tag_list = '{}' WHERE tag_list IS NULL;
+
+ALTER TABLE articles
+    ALTER COLUMN tag_list SET DEFAULT '{}',
+    ALTER COLUMN tag_list SET NOT NULL;
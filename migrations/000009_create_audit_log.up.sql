@@ -0,0 +1,29 @@
+-- Append-only record of content mutations, for operators only.
+-- actor_id deliberately has no foreign key so that entries outlive the user.
+CREATE TABLE audit_log
+(
+    id          BIGSERIAL PRIMARY KEY,
+    actor_id    BIGINT    NOT NULL,
+    action      TEXT      NOT NULL,
+    target_type TEXT      NOT NULL,
+    target_id   BIGINT    NOT NULL,
+    target_slug TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_audit_log_target ON audit_log (target_type, target_id);
+CREATE INDEX idx_audit_log_actor_id ON audit_log (actor_id);
+
+-- Reject updates and deletes so that the log stays append-only
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE
+    ON audit_log
+    FOR EACH ROW
+EXECUTE FUNCTION audit_log_append_only();
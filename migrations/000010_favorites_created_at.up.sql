@@ -0,0 +1,5 @@
+-- Record when each favorite was made. Existing favorites are stamped with the migration time.
+ALTER TABLE favorites
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC');
+
+CREATE INDEX idx_favorites_created_at ON favorites (created_at);
@@ -0,0 +1,4 @@
+-- How clients should render the article body. Existing articles are markdown.
+ALTER TABLE articles
+    ADD COLUMN body_type TEXT NOT NULL DEFAULT 'markdown'
+        CONSTRAINT articles_body_type_check CHECK (body_type IN ('markdown', 'plain'));
@@ -0,0 +1,3 @@
+-- Whether other users can list the articles a user has favorited. Existing users stay public.
+ALTER TABLE users
+    ADD COLUMN favorites_public BOOLEAN NOT NULL DEFAULT true;
@@ -0,0 +1 @@
+ALTER TABLE follows DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,3 @@
+-- Record when each follow was made. Existing follows are stamped with the migration time.
+ALTER TABLE follows
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC');
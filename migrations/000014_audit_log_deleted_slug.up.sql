@@ -0,0 +1,3 @@
+-- Supports looking up whether a slug belonged to a since deleted article
+CREATE INDEX idx_audit_log_deleted_article_slug ON audit_log (target_slug)
+    WHERE action = 'delete' AND target_type = 'article';
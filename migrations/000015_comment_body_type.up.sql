@@ -0,0 +1,4 @@
+-- How clients should render the comment body, like articles. Existing comments are markdown.
+ALTER TABLE comments
+    ADD COLUMN body_type TEXT NOT NULL DEFAULT 'markdown'
+        CONSTRAINT comments_body_type_check CHECK (body_type IN ('markdown', 'plain'));
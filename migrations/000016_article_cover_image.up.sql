@@ -0,0 +1,3 @@
+-- Optional https URL of an image shown above the article. Empty means no cover image.
+ALTER TABLE articles
+    ADD COLUMN cover_image TEXT NOT NULL DEFAULT '';